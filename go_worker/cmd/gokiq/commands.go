@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gokiq/internal/blob"
+	"gokiq/internal/config"
+	"gokiq/internal/encryption"
+	"gokiq/internal/job"
+	"gokiq/internal/redis"
+	"gokiq/internal/replay"
+	"gokiq/internal/worker"
+)
+
+func runWork(cfg *config.Config, args []string) error {
+	w, err := worker.New(cfg)
+	if err != nil {
+		return err
+	}
+	return w.Run()
+}
+
+func runStats(cfg *config.Config, args []string) error {
+	client, err := redis.NewClient(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	stats, err := client.Stats()
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter()
+	fmt.Fprintf(tw, "Processed:\t%d\n", stats.Processed)
+	fmt.Fprintf(tw, "Failed:\t%d\n", stats.Failed)
+	fmt.Fprintf(tw, "Expired:\t%d\n", stats.Expired)
+	fmt.Fprintf(tw, "Enqueued:\t%d\n", stats.Enqueued)
+	fmt.Fprintf(tw, "Scheduled:\t%d\n", stats.Scheduled)
+	fmt.Fprintf(tw, "Retries:\t%d\n", stats.Retries)
+	fmt.Fprintf(tw, "Dead:\t%d\n", stats.Dead)
+	fmt.Fprintf(tw, "Processes:\t%d\n", stats.Processes)
+	return tw.Flush()
+}
+
+func runQueues(cfg *config.Config, args []string) error {
+	client, err := redis.NewClient(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	queues, err := client.Queues()
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter()
+	fmt.Fprintln(tw, "QUEUE\tSIZE\tLATENCY")
+	for _, q := range queues {
+		fmt.Fprintf(tw, "%s\t%d\t%.2fs\n", q.Name, q.Size, q.Latency)
+	}
+	return tw.Flush()
+}
+
+func runRetry(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected subcommand: list or run")
+	}
+
+	client, err := redis.NewClient(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	switch args[0] {
+	case "list":
+		return listSet(client, redis.RetrySet, args[1:])
+	case "run":
+		return retryJobs(client, redis.RetrySet, args[1:])
+	default:
+		return fmt.Errorf("unknown subcommand: %s", args[0])
+	}
+}
+
+func runDead(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected subcommand: list, replay or purge")
+	}
+
+	client, err := redis.NewClient(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	switch args[0] {
+	case "list":
+		return listSet(client, redis.DeadSet, args[1:])
+	case "replay":
+		return replayDead(client, args[1:])
+	case "purge":
+		size, err := client.SetSize(redis.DeadSet)
+		if err != nil {
+			return err
+		}
+		if err := client.ClearSet(redis.DeadSet); err != nil {
+			return err
+		}
+		fmt.Printf("Purged %d dead jobs\n", size)
+		return nil
+	default:
+		return fmt.Errorf("unknown subcommand: %s", args[0])
+	}
+}
+
+func runEnqueue(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("enqueue", flag.ContinueOnError)
+	queue := flags.String("queue", "default", "queue to push the job onto")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: gokiq enqueue [-queue name] <Class> <json-args>")
+	}
+
+	var jobArgs []interface{}
+	if err := json.Unmarshal([]byte(flags.Arg(1)), &jobArgs); err != nil {
+		return fmt.Errorf("args must be a JSON array: %w", err)
+	}
+
+	client, err := redis.NewClient(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if cfg.Encryption.Enabled {
+		keyring, err := encryption.NewKeyring(cfg.Encryption)
+		if err != nil {
+			return err
+		}
+		client.SetEncrypter(keyring)
+	}
+
+	store, err := blob.NewStore(cfg.Blob)
+	if err != nil {
+		return fmt.Errorf("failed to initialize blob store: %w", err)
+	}
+	if store != nil && cfg.Blob.Threshold > 0 {
+		client.SetOffloader(blob.NewOffloader(store, cfg.Blob.Threshold))
+	}
+
+	newJob := &job.SidekiqJob{
+		Class: flags.Arg(0),
+		Args:  jobArgs,
+		Queue: *queue,
+	}
+	if err := client.Enqueue(newJob); err != nil {
+		return err
+	}
+
+	fmt.Printf("Enqueued %s to %s (jid: %s)\n", newJob.Class, newJob.Queue, newJob.JID)
+	return nil
+}
+
+func runProcesses(cfg *config.Config, args []string) error {
+	client, err := redis.NewClient(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	processes, err := client.Processes()
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter()
+	fmt.Fprintln(tw, "IDENTITY\tTAG\tBUSY\tCONCURRENCY\tQUEUES\tSTARTED\tLAST BEAT\tSTATE")
+	for _, p := range processes {
+		state := "running"
+		if p.Quiet {
+			state = "quiet"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\t%s ago\t%s\n",
+			p.Info.Identity, p.Info.Tag, p.Busy, p.Info.Concurrency,
+			strings.Join(p.Info.Queues, ","), formatTime(p.Info.StartedAt),
+			time.Since(unixTime(p.Beat)).Round(time.Second), state)
+	}
+	return tw.Flush()
+}
+
+func runSignal(cfg *config.Config, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: gokiq signal <identity> <quiet|stop|dump>")
+	}
+
+	signals := map[string]string{"quiet": "TSTP", "stop": "TERM", "dump": "TTIN"}
+	signal, ok := signals[args[1]]
+	if !ok {
+		return fmt.Errorf("unknown signal %q, expected quiet, stop or dump", args[1])
+	}
+
+	client, err := redis.NewClient(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.SendSignal(args[0], signal); err != nil {
+		return err
+	}
+	fmt.Printf("Sent %s to %s, it will be handled on the next heartbeat\n", signal, args[0])
+	return nil
+}
+
+func runBatch(cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: gokiq batch <bid>")
+	}
+
+	client, err := redis.NewClient(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	status, err := client.BatchStatus(args[0])
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return fmt.Errorf("batch %s not found", args[0])
+	}
+
+	tw := newTabWriter()
+	fmt.Fprintf(tw, "BID:\t%s\n", status.BID)
+	fmt.Fprintf(tw, "Description:\t%s\n", status.Description)
+	fmt.Fprintf(tw, "Created:\t%s\n", formatTime(status.CreatedAt))
+	fmt.Fprintf(tw, "Total:\t%d\n", status.Total)
+	fmt.Fprintf(tw, "Pending:\t%d\n", status.Pending)
+	fmt.Fprintf(tw, "Failures:\t%d\n", status.Failures)
+	if status.CompleteAt > 0 {
+		fmt.Fprintf(tw, "Complete:\t%s\n", formatTime(status.CompleteAt))
+	}
+	if status.SuccessAt > 0 {
+		fmt.Fprintf(tw, "Succeeded:\t%s\n", formatTime(status.SuccessAt))
+	}
+	if len(status.FailedJIDs) > 0 {
+		fmt.Fprintf(tw, "Failed JIDs:\t%s\n", strings.Join(status.FailedJIDs, ","))
+	}
+	return tw.Flush()
+}
+
+// listSet prints a page of a sorted set
+func listSet(client *redis.Client, set string, args []string) error {
+	flags := flag.NewFlagSet(set+" list", flag.ContinueOnError)
+	offset := flags.Int64("offset", 0, "number of entries to skip")
+	limit := flags.Int64("limit", 25, "maximum number of entries to show")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	size, err := client.SetSize(set)
+	if err != nil {
+		return err
+	}
+	entries, err := client.ListSet(set, *offset, *limit)
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter()
+	fmt.Fprintln(tw, "JID\tCLASS\tQUEUE\tRETRY\tAT\tERROR")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			e.Job.JID, e.Job.Class, e.Job.Queue, e.Job.Retry, formatTime(e.Score), e.Job.ErrorMsg)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nShowing %d of %d entries in %s\n", len(entries), size, set)
+	return nil
+}
+
+// replayDead replays the given JIDs, or every dead job matching the filter flags
+func replayDead(client *redis.Client, args []string) error {
+	flags := flag.NewFlagSet("dead replay", flag.ContinueOnError)
+	var opts replay.Options
+	flags.StringVar(&opts.Class, "class", "", "only replay jobs of this class")
+	flags.StringVar(&opts.Queue, "queue", "", "only replay jobs from this queue")
+	flags.StringVar(&opts.ErrorClass, "error-class", "", "regular expression matched against the error class")
+	flags.StringVar(&opts.ErrorMessage, "error-message", "", "regular expression matched against the error message")
+	since := flags.String("since", "", "only replay jobs that died at or after this RFC3339 time")
+	until := flags.String("until", "", "only replay jobs that died at or before this RFC3339 time")
+	flags.Float64Var(&opts.Rate, "rate", 50, "maximum jobs replayed per second (0 for unthrottled)")
+	flags.Int64Var(&opts.Limit, "limit", 0, "maximum number of jobs to replay (0 for no limit)")
+	flags.BoolVar(&opts.DryRun, "dry-run", false, "count matching jobs without replaying them")
+	flags.BoolVar(&opts.All, "all", false, "replay every dead job when no other filter is given")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		return replayJobs(client, flags.Args())
+	}
+
+	var err error
+	if *since != "" {
+		if opts.Since, err = time.Parse(time.RFC3339, *since); err != nil {
+			return fmt.Errorf("invalid -since: %w", err)
+		}
+	}
+	if *until != "" {
+		if opts.Until, err = time.Parse(time.RFC3339, *until); err != nil {
+			return fmt.Errorf("invalid -until: %w", err)
+		}
+	}
+
+	if !opts.Filtered() && !opts.All && !opts.DryRun {
+		return fmt.Errorf("expected JIDs, a filter flag or -all")
+	}
+
+	// Print progress at most once a second
+	var lastReport time.Time
+	progress, err := replay.Run(context.Background(), client, opts, func(p replay.Progress) {
+		if p.Done || time.Since(lastReport) >= time.Second {
+			lastReport = time.Now()
+			fmt.Printf("scanned=%d matched=%d replayed=%d skipped=%d failed=%d\n",
+				p.Scanned, p.Matched, p.Replayed, p.Skipped, p.Failed)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if progress.DryRun {
+		fmt.Printf("Dry run: %d dead jobs match\n", progress.Matched)
+	} else if progress.Failed > 0 {
+		return fmt.Errorf("%d of %d jobs could not be replayed", progress.Failed, progress.Matched)
+	}
+	return nil
+}
+
+// replayJobs replays the given dead JIDs with their retry state reset, like a
+// filtered replay
+func replayJobs(client *redis.Client, jids []string) error {
+	var failed int
+	for _, jid := range jids {
+		entry, err := client.FindInSet(redis.DeadSet, jid)
+		if err == nil {
+			var moved bool
+			if moved, err = replay.Entry(client, entry); err == nil && !moved {
+				err = redis.ErrJobNotFound
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", jid, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: enqueued\n", jid)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs could not be enqueued", failed, len(jids))
+	}
+	return nil
+}
+
+// retryJobs moves the given JIDs from a sorted set back onto their queues
+func retryJobs(client *redis.Client, set string, jids []string) error {
+	if len(jids) == 0 {
+		return fmt.Errorf("expected at least one JID")
+	}
+
+	var failed int
+	for _, jid := range jids {
+		if err := client.RetryFromSet(set, jid); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", jid, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: enqueued\n", jid)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs could not be enqueued", failed, len(jids))
+	}
+	return nil
+}
+
+func newTabWriter() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+}
+
+func unixTime(ts float64) time.Time {
+	return time.Unix(0, int64(ts*1e9))
+}
+
+func formatTime(ts float64) string {
+	if ts == 0 {
+		return "-"
+	}
+	return unixTime(ts).Format(time.RFC3339)
+}
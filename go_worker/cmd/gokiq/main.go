@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gokiq/internal/config"
+)
+
+// command is a gokiq subcommand
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{"work", "work", "Run a worker process", runWork},
+	{"stats", "stats", "Show processed/failed counters and set sizes", runStats},
+	{"queues", "queues", "List queues with size and latency", runQueues},
+	{"retry", "retry list [-offset N] [-limit N] | retry run <jid>...", "Inspect or retry jobs in the retry set", runRetry},
+	{"dead", "dead list [-offset N] [-limit N] | dead replay <jid>... | dead replay [filters] | dead purge", "Inspect, replay or purge the dead set", runDead},
+	{"enqueue", "enqueue [-queue name] <Class> <json-args>", "Push a new job onto a queue", runEnqueue},
+	{"processes", "processes", "List running worker processes", runProcesses},
+	{"batch", "batch <bid>", "Show the progress of a batch", runBatch},
+	{"signal", "signal <identity> <quiet|stop|dump>", "Ask a worker process to quiet, stop or dump its state", runSignal},
+}
+
+func main() {
+	flags := flag.NewFlagSet("gokiq", flag.ExitOnError)
+	configFlags := config.RegisterFlags(flags)
+	flags.Usage = func() { usage(flags) }
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		usage(flags)
+		os.Exit(2)
+	}
+
+	name := flags.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		cfg, err := configFlags.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := cmd.run(cfg, flags.Args()[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "gokiq %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+	usage(flags)
+	os.Exit(2)
+}
+
+func usage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintf(out, "Usage: gokiq [-config path] [-concurrency N] [-queues a,b] <command> [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+		fmt.Fprintf(out, "  %-10s   gokiq %s\n", "", cmd.usage)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	flags.PrintDefaults()
+}
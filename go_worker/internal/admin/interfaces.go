@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"gokiq/internal/concurrency"
+	"gokiq/internal/redis"
+	"gokiq/internal/replay"
+	"gokiq/internal/sidecar"
+)
+
+// Store defines the Redis operations required by the admin API
+type Store interface {
+	replay.Store
+
+	// Queues returns every known queue with its size and latency
+	Queues() ([]redis.QueueInfo, error)
+
+	// ClearQueue deletes all jobs in a queue
+	ClearQueue(queueName string) error
+
+	// SetSize returns the number of entries in a sorted set
+	SetSize(set string) (int64, error)
+
+	// ListSet returns a page of entries from a sorted set
+	ListSet(set string, offset, limit int64) ([]redis.SetEntry, error)
+
+	// FindInSet looks up a job by JID in a sorted set
+	FindInSet(set, jid string) (*redis.SetEntry, error)
+
+	// DeleteFromSet removes a job from a sorted set
+	DeleteFromSet(set, jid string) error
+
+	// RetryFromSet moves a job from a sorted set back onto its queue
+	RetryFromSet(set, jid string) error
+
+	// KillFromSet moves a job from a sorted set into the dead set
+	KillFromSet(set, jid string) error
+
+	// ClearSet deletes every entry in a sorted set
+	ClearSet(set string) error
+
+	// Workflow returns the state of a workflow, or nil if it does not exist
+	Workflow(id string) (*redis.WorkflowStatus, error)
+
+	// Workflows returns the IDs of the most recent workflows, newest first
+	Workflows(offset, count int64) ([]string, error)
+}
+
+// ConcurrencyReporter exposes the state of adaptive concurrency control
+type ConcurrencyReporter interface {
+	// Status returns the current limit and recent limit changes
+	Status() concurrency.AdaptiveStatus
+}
+
+// PoolStats reports the Redis pool and the pool of every sidecar executor
+type PoolStats struct {
+	Redis    redis.PoolStats              `json:"redis"`
+	Sidecars map[string]sidecar.PoolStats `json:"sidecars"`
+}
+
+// PoolReporter exposes connection pool usage
+type PoolReporter interface {
+	// PoolStats returns the current usage of every connection pool
+	PoolStats() PoolStats
+}
@@ -0,0 +1,385 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gokiq/internal/config"
+	"gokiq/internal/redis"
+	"gokiq/internal/replay"
+)
+
+const (
+	defaultAddr     = ":7433"
+	defaultPageSize = 25
+	maxPageSize     = 1000
+)
+
+// Server exposes queues and the schedule, retry and dead sets as a JSON API
+type Server struct {
+	store      Store
+	replays    *replay.Manager
+	adaptive   ConcurrencyReporter
+	pools      PoolReporter
+	token      string
+	mux        *http.ServeMux
+	httpServer *http.Server
+}
+
+// NewServer creates a new admin API server
+func NewServer(cfg config.AdminConfig, store Store) *Server {
+	addr := cfg.Addr
+	if addr == "" {
+		addr = defaultAddr
+	}
+
+	s := &Server{
+		store:   store,
+		replays: replay.NewManager(store),
+		token:   cfg.Token,
+		mux:     http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /api/queues", s.handleListQueues)
+	s.mux.HandleFunc("DELETE /api/queues/{queue}", s.handleClearQueue)
+	s.mux.HandleFunc("GET /api/sets/{set}", s.handleListSet)
+	s.mux.HandleFunc("DELETE /api/sets/{set}", s.handleClearSet)
+	s.mux.HandleFunc("GET /api/sets/{set}/{jid}", s.handleGetEntry)
+	s.mux.HandleFunc("DELETE /api/sets/{set}/{jid}", s.handleDeleteEntry)
+	s.mux.HandleFunc("POST /api/sets/{set}/{jid}/retry", s.handleRetryEntry)
+	s.mux.HandleFunc("POST /api/sets/{set}/{jid}/kill", s.handleKillEntry)
+	s.mux.HandleFunc("GET /api/replays", s.handleListReplays)
+	s.mux.HandleFunc("POST /api/replays", s.handleStartReplay)
+	s.mux.HandleFunc("GET /api/replays/{id}", s.handleGetReplay)
+	s.mux.HandleFunc("DELETE /api/replays/{id}", s.handleCancelReplay)
+	s.mux.HandleFunc("GET /api/concurrency", s.handleConcurrency)
+	s.mux.HandleFunc("GET /api/pools", s.handlePools)
+	s.mux.HandleFunc("GET /api/workflows", s.handleListWorkflows)
+	s.mux.HandleFunc("GET /api/workflows/{id}", s.handleGetWorkflow)
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	return s
+}
+
+// SetConcurrencyReporter exposes adaptive concurrency decisions at /api/concurrency
+func (s *Server) SetConcurrencyReporter(reporter ConcurrencyReporter) {
+	s.adaptive = reporter
+}
+
+// SetPoolReporter exposes connection pool usage at /api/pools
+func (s *Server) SetPoolReporter(reporter PoolReporter) {
+	s.pools = reporter
+}
+
+// ServeHTTP authenticates the request and dispatches it to the matching handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		expected := "Bearer " + s.token
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+			return
+		}
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe starts serving the admin API until Shutdown is called
+func (s *Server) ListenAndServe() error {
+	log.Printf("Admin API listening on %s", s.httpServer.Addr)
+	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("admin API server failed: %w", err)
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the admin API
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *Server) handleListQueues(w http.ResponseWriter, r *http.Request) {
+	queues, err := s.store.Queues()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"queues": queues})
+}
+
+func (s *Server) handleClearQueue(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.ClearQueue(r.PathValue("queue")); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListSet(w http.ResponseWriter, r *http.Request) {
+	set, ok := setFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid offset"))
+		return
+	}
+	limit, err := queryInt(r, "limit", defaultPageSize)
+	if err != nil || limit <= 0 || limit > maxPageSize {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxPageSize))
+		return
+	}
+
+	size, err := s.store.SetSize(set)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	entries, err := s.store.ListSet(set, offset, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"set":     set,
+		"size":    size,
+		"offset":  offset,
+		"limit":   limit,
+		"entries": entries,
+	})
+}
+
+func (s *Server) handleClearSet(w http.ResponseWriter, r *http.Request) {
+	set, ok := setFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.store.ClearSet(set); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetEntry(w http.ResponseWriter, r *http.Request) {
+	set, ok := setFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := s.store.FindInSet(set, r.PathValue("jid"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (s *Server) handleDeleteEntry(w http.ResponseWriter, r *http.Request) {
+	s.handleEntryAction(w, r, s.store.DeleteFromSet)
+}
+
+func (s *Server) handleRetryEntry(w http.ResponseWriter, r *http.Request) {
+	s.handleEntryAction(w, r, s.store.RetryFromSet)
+}
+
+func (s *Server) handleKillEntry(w http.ResponseWriter, r *http.Request) {
+	set, ok := setFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if set == redis.DeadSet {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("job is already dead"))
+		return
+	}
+
+	s.handleEntryAction(w, r, s.store.KillFromSet)
+}
+
+// handleEntryAction applies a single-entry operation identified by set and JID
+func (s *Server) handleEntryAction(w http.ResponseWriter, r *http.Request, action func(set, jid string) error) {
+	set, ok := setFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := action(set, r.PathValue("jid")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListReplays(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"replays": s.replays.List()})
+}
+
+func (s *Server) handleStartReplay(w http.ResponseWriter, r *http.Request) {
+	var opts replay.Options
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid replay options: %w", err))
+			return
+		}
+	}
+
+	id, err := s.replays.Start(opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	progress, _ := s.replays.Get(id)
+	writeJSON(w, http.StatusAccepted, progress)
+}
+
+func (s *Server) handleGetReplay(w http.ResponseWriter, r *http.Request) {
+	progress, ok := s.replays.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("replay not found"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, progress)
+}
+
+func (s *Server) handleCancelReplay(w http.ResponseWriter, r *http.Request) {
+	if !s.replays.Cancel(r.PathValue("id")) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("replay not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleConcurrency(w http.ResponseWriter, r *http.Request) {
+	if s.adaptive == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("adaptive concurrency is disabled"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.adaptive.Status())
+}
+
+func (s *Server) handlePools(w http.ResponseWriter, r *http.Request) {
+	if s.pools == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("pool statistics are not available"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.pools.PoolStats())
+}
+
+func (s *Server) handleListWorkflows(w http.ResponseWriter, r *http.Request) {
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid offset"))
+		return
+	}
+	limit, err := queryInt(r, "limit", defaultPageSize)
+	if err != nil || limit <= 0 || limit > maxPageSize {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxPageSize))
+		return
+	}
+
+	ids, err := s.store.Workflows(offset, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	workflows := make([]*redis.WorkflowStatus, 0, len(ids))
+	for _, id := range ids {
+		workflow, err := s.store.Workflow(id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		// Skip workflows that expired after being listed
+		if workflow != nil {
+			workflows = append(workflows, workflow)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"offset":    offset,
+		"limit":     limit,
+		"workflows": workflows,
+	})
+}
+
+func (s *Server) handleGetWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflow, err := s.store.Workflow(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if workflow == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("workflow %s not found", r.PathValue("id")))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, workflow)
+}
+
+// setFromRequest validates the sorted set named in the request path
+func setFromRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	set := r.PathValue("set")
+	switch set {
+	case redis.ScheduleSet, redis.RetrySet, redis.DeadSet, redis.ExpiredSet:
+		return set, true
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown set: %s", set))
+		return "", false
+	}
+}
+
+// queryInt parses an integer query parameter, falling back to def when absent
+func queryInt(r *http.Request, name string, def int64) (int64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, redis.ErrJobNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to encode admin API response: %v", err)
+	}
+}
@@ -0,0 +1,410 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gokiq/internal/concurrency"
+	"gokiq/internal/config"
+	"gokiq/internal/job"
+	"gokiq/internal/redis"
+	"gokiq/internal/replay"
+	"gokiq/internal/sidecar"
+)
+
+// fakeStore is an in-memory Store for exercising the HTTP layer
+type fakeStore struct {
+	queues    []redis.QueueInfo
+	sets      map[string][]redis.SetEntry
+	cleared   []string
+	actions   []string
+	workflows []*redis.WorkflowStatus
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		queues: []redis.QueueInfo{{Name: "default", Size: 3, Latency: 1.5}},
+		sets: map[string][]redis.SetEntry{
+			redis.RetrySet: {
+				{Score: 100, Job: &job.SidekiqJob{JID: "jid-1", Class: "TestJob", Queue: "default"}},
+				{Score: 200, Job: &job.SidekiqJob{JID: "jid-2", Class: "TestJob", Queue: "default"}},
+			},
+		},
+	}
+}
+
+func (f *fakeStore) Queues() ([]redis.QueueInfo, error) { return f.queues, nil }
+
+func (f *fakeStore) Workflow(id string) (*redis.WorkflowStatus, error) {
+	for _, workflow := range f.workflows {
+		if workflow.ID == id {
+			return workflow, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) Workflows(offset, count int64) ([]string, error) {
+	var ids []string
+	for i := offset; i < int64(len(f.workflows)) && i < offset+count; i++ {
+		ids = append(ids, f.workflows[i].ID)
+	}
+	return ids, nil
+}
+
+func (f *fakeStore) ClearQueue(queueName string) error {
+	f.cleared = append(f.cleared, "queue:"+queueName)
+	return nil
+}
+
+func (f *fakeStore) SetSize(set string) (int64, error) { return int64(len(f.sets[set])), nil }
+
+func (f *fakeStore) ListSet(set string, offset, limit int64) ([]redis.SetEntry, error) {
+	entries := f.sets[set]
+	if offset >= int64(len(entries)) {
+		return []redis.SetEntry{}, nil
+	}
+	end := offset + limit
+	if end > int64(len(entries)) {
+		end = int64(len(entries))
+	}
+	return entries[offset:end], nil
+}
+
+func (f *fakeStore) FindInSet(set, jid string) (*redis.SetEntry, error) {
+	for _, entry := range f.sets[set] {
+		if entry.Job.JID == jid {
+			return &entry, nil
+		}
+	}
+	return nil, redis.ErrJobNotFound
+}
+
+func (f *fakeStore) action(name string) func(set, jid string) error {
+	return func(set, jid string) error {
+		if _, err := f.FindInSet(set, jid); err != nil {
+			return err
+		}
+		f.actions = append(f.actions, fmt.Sprintf("%s:%s:%s", name, set, jid))
+		return nil
+	}
+}
+
+func (f *fakeStore) DeleteFromSet(set, jid string) error { return f.action("delete")(set, jid) }
+func (f *fakeStore) RetryFromSet(set, jid string) error  { return f.action("retry")(set, jid) }
+func (f *fakeStore) KillFromSet(set, jid string) error   { return f.action("kill")(set, jid) }
+
+func (f *fakeStore) ScanSet(set, min, max string, offset, count int64) ([]redis.SetEntry, error) {
+	return f.ListSet(set, offset, count)
+}
+
+func (f *fakeStore) RequeueFromSet(set string, entry *redis.SetEntry) (bool, error) {
+	f.actions = append(f.actions, fmt.Sprintf("requeue:%s:%s", set, entry.Job.JID))
+	return true, nil
+}
+
+func (f *fakeStore) ClearSet(set string) error {
+	f.cleared = append(f.cleared, set)
+	return nil
+}
+
+func doRequest(t *testing.T, handler http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_ListQueues(t *testing.T) {
+	server := NewServer(config.AdminConfig{}, newFakeStore())
+
+	rec := doRequest(t, server, "GET", "/api/queues", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var body struct {
+		Queues []redis.QueueInfo `json:"queues"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Queues) != 1 || body.Queues[0].Name != "default" || body.Queues[0].Size != 3 {
+		t.Errorf("Unexpected queues response: %+v", body.Queues)
+	}
+}
+
+func TestServer_ListSet_Paging(t *testing.T) {
+	server := NewServer(config.AdminConfig{}, newFakeStore())
+
+	rec := doRequest(t, server, "GET", "/api/sets/retry?offset=1&limit=1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var body struct {
+		Size    int64            `json:"size"`
+		Entries []redis.SetEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Size != 2 {
+		t.Errorf("Expected size 2, got %d", body.Size)
+	}
+	if len(body.Entries) != 1 || body.Entries[0].Job.JID != "jid-2" {
+		t.Errorf("Expected second page to contain jid-2, got %+v", body.Entries)
+	}
+}
+
+func TestServer_InvalidRequests(t *testing.T) {
+	server := NewServer(config.AdminConfig{}, newFakeStore())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"unknown set", "GET", "/api/sets/bogus", http.StatusNotFound},
+		{"invalid limit", "GET", "/api/sets/retry?limit=0", http.StatusBadRequest},
+		{"invalid offset", "GET", "/api/sets/retry?offset=-1", http.StatusBadRequest},
+		{"missing job", "POST", "/api/sets/retry/missing/retry", http.StatusNotFound},
+		{"kill dead job", "POST", "/api/sets/dead/jid-1/kill", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, server, tt.method, tt.path, "")
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestServer_EntryActions(t *testing.T) {
+	store := newFakeStore()
+	server := NewServer(config.AdminConfig{}, store)
+
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{"POST", "/api/sets/retry/jid-1/retry"},
+		{"POST", "/api/sets/retry/jid-1/kill"},
+		{"DELETE", "/api/sets/retry/jid-2"},
+	}
+	for _, req := range requests {
+		rec := doRequest(t, server, req.method, req.path, "")
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("%s %s: expected status 204, got %d", req.method, req.path, rec.Code)
+		}
+	}
+
+	want := []string{"retry:retry:jid-1", "kill:retry:jid-1", "delete:retry:jid-2"}
+	if len(store.actions) != len(want) {
+		t.Fatalf("Expected actions %v, got %v", want, store.actions)
+	}
+	for i := range want {
+		if store.actions[i] != want[i] {
+			t.Errorf("Expected action %s, got %s", want[i], store.actions[i])
+		}
+	}
+}
+
+func TestServer_Clear(t *testing.T) {
+	store := newFakeStore()
+	server := NewServer(config.AdminConfig{}, store)
+
+	if rec := doRequest(t, server, "DELETE", "/api/queues/default", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 clearing queue, got %d", rec.Code)
+	}
+	if rec := doRequest(t, server, "DELETE", "/api/sets/dead", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 clearing set, got %d", rec.Code)
+	}
+
+	if len(store.cleared) != 2 || store.cleared[0] != "queue:default" || store.cleared[1] != "dead" {
+		t.Errorf("Unexpected cleared keys: %v", store.cleared)
+	}
+}
+
+func TestServer_TokenAuth(t *testing.T) {
+	server := NewServer(config.AdminConfig{Token: "secret"}, newFakeStore())
+
+	if rec := doRequest(t, server, "GET", "/api/queues", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token, got %d", rec.Code)
+	}
+	if rec := doRequest(t, server, "GET", "/api/queues", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 with wrong token, got %d", rec.Code)
+	}
+	if rec := doRequest(t, server, "GET", "/api/queues", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with valid token, got %d", rec.Code)
+	}
+}
+
+func TestServer_Replays(t *testing.T) {
+	store := newFakeStore()
+	store.sets[redis.DeadSet] = []redis.SetEntry{
+		{Score: 100, Job: &job.SidekiqJob{JID: "dead-1", Class: "TestJob", Queue: "default"}},
+	}
+	server := NewServer(config.AdminConfig{}, store)
+
+	req := httptest.NewRequest("POST", "/api/replays", strings.NewReader(`{"class": "TestJob"}`))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var started replay.Progress
+	if err := json.NewDecoder(rec.Body).Decode(&started); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	var progress replay.Progress
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		rec := doRequest(t, server, "GET", "/api/replays/"+started.ID, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		json.NewDecoder(rec.Body).Decode(&progress)
+		if progress.Done {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !progress.Done || progress.Replayed != 1 {
+		t.Errorf("Expected finished replay of 1 job, got %+v", progress)
+	}
+
+	req = httptest.NewRequest("POST", "/api/replays", strings.NewReader(`{"error_class": "("}`))
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid pattern, got %d", rec.Code)
+	}
+
+	// Replaying the whole dead set must be explicit
+	for _, body := range []string{"", "{}"} {
+		req = httptest.NewRequest("POST", "/api/replays", strings.NewReader(body))
+		rec = httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for unfiltered replay %q, got %d", body, rec.Code)
+		}
+	}
+
+	if rec := doRequest(t, server, "GET", "/api/replays/missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown replay, got %d", rec.Code)
+	}
+}
+
+type fakeReporter struct{}
+
+func (fakeReporter) Status() concurrency.AdaptiveStatus {
+	return concurrency.AdaptiveStatus{Limit: 12, Min: 1, Max: 50}
+}
+
+func TestServer_Concurrency(t *testing.T) {
+	server := NewServer(config.AdminConfig{}, newFakeStore())
+
+	if rec := doRequest(t, server, "GET", "/api/concurrency", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when adaptive concurrency is disabled, got %d", rec.Code)
+	}
+
+	server.SetConcurrencyReporter(fakeReporter{})
+	rec := doRequest(t, server, "GET", "/api/concurrency", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var status concurrency.AdaptiveStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if status.Limit != 12 || status.Max != 50 {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+}
+
+type fakePools struct{}
+
+func (fakePools) PoolStats() PoolStats {
+	return PoolStats{
+		Redis:    redis.PoolStats{Size: 135, InUse: 40},
+		Sidecars: map[string]sidecar.PoolStats{"sidecar": {MaxConns: 500, Active: 500, Waiting: 3}},
+	}
+}
+
+func TestServer_Pools(t *testing.T) {
+	server := NewServer(config.AdminConfig{}, newFakeStore())
+
+	if rec := doRequest(t, server, "GET", "/api/pools", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a pool reporter, got %d", rec.Code)
+	}
+
+	server.SetPoolReporter(fakePools{})
+	rec := doRequest(t, server, "GET", "/api/pools", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var stats PoolStats
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if stats.Redis.Size != 135 || stats.Sidecars["sidecar"].Waiting != 3 {
+		t.Errorf("Unexpected pool stats: %+v", stats)
+	}
+}
+
+func TestServer_Workflows(t *testing.T) {
+	store := newFakeStore()
+	store.workflows = []*redis.WorkflowStatus{
+		{ID: "wf-2", Policy: "skip", Status: "running", Steps: []redis.WorkflowStepStatus{
+			{Name: "extract", Status: "succeeded", Parents: []string{}},
+			{Name: "load", Status: "enqueued", Parents: []string{"extract"}},
+		}},
+		{ID: "wf-1", Policy: "halt", Status: "halted"},
+	}
+	server := NewServer(config.AdminConfig{}, store)
+
+	rec := doRequest(t, server, "GET", "/api/workflows?limit=1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var list struct {
+		Workflows []redis.WorkflowStatus `json:"workflows"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list.Workflows) != 1 || list.Workflows[0].ID != "wf-2" {
+		t.Errorf("Expected the newest workflow only, got %+v", list.Workflows)
+	}
+
+	rec = doRequest(t, server, "GET", "/api/workflows/wf-2", "")
+	var workflow redis.WorkflowStatus
+	if err := json.NewDecoder(rec.Body).Decode(&workflow); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(workflow.Steps) != 2 || workflow.Steps[1].Status != "enqueued" {
+		t.Errorf("Unexpected workflow: %+v", workflow)
+	}
+
+	if rec := doRequest(t, server, "GET", "/api/workflows/missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown workflow, got %d", rec.Code)
+	}
+}
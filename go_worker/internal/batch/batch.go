@@ -0,0 +1,100 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"gokiq/internal/concurrency"
+	"gokiq/internal/job"
+)
+
+// Callback is a job pushed when a batch finishes. It receives the batch ID
+// as its first argument, followed by Args.
+type Callback struct {
+	Class string
+	Queue string
+	Args  []interface{}
+}
+
+// Batch is a group of jobs tracked together. OnComplete runs once every job
+// has run at least once, whether or not it succeeded; OnSuccess runs once
+// every job has succeeded.
+type Batch struct {
+	BID         string
+	Description string
+	OnComplete  *Callback
+	OnSuccess   *Callback
+
+	jobs []*job.SidekiqJob
+}
+
+// New creates an empty batch with a fresh BID
+func New(description string) *Batch {
+	return &Batch{
+		BID:         job.NewJID(),
+		Description: description,
+	}
+}
+
+// Add adds jobs to the batch; they are pushed when the batch is run
+func (b *Batch) Add(jobs ...*job.SidekiqJob) {
+	b.jobs = append(b.jobs, jobs...)
+}
+
+// Jobs returns the jobs added so far
+func (b *Batch) Jobs() []*job.SidekiqJob {
+	return b.jobs
+}
+
+// Run records the batch and pushes its jobs
+func (b *Batch) Run(store Store) error {
+	if err := store.CreateBatch(b.BID, b.Description, b.jobs, b.callbackJob(b.OnComplete), b.callbackJob(b.OnSuccess)); err != nil {
+		return fmt.Errorf("failed to run batch: %w", err)
+	}
+	return nil
+}
+
+// callbackJob builds the job pushed for a callback
+func (b *Batch) callbackJob(callback *Callback) *job.SidekiqJob {
+	if callback == nil {
+		return nil
+	}
+	return &job.SidekiqJob{
+		Class: callback.Class,
+		Queue: callback.Queue,
+		Args:  append([]interface{}{b.BID}, callback.Args...),
+	}
+}
+
+// Middleware is server middleware that records the outcome of every batch
+// job. It should run first so it sees failures raised by other middleware.
+func Middleware(store Store) concurrency.Middleware {
+	return func(ctx context.Context, jobData *job.SidekiqJob, next concurrency.Next) (*job.JobResult, error) {
+		result, err := next(ctx, jobData)
+		if jobData.BID == "" || errors.Is(err, concurrency.ErrRequeued) {
+			return result, err
+		}
+
+		success := err == nil && result != nil && result.Status == "success"
+		if recordErr := store.RecordBatchOutcome(jobData.BID, jobData.JID, success); recordErr != nil {
+			log.Printf("Failed to update batch: BID=%s, JID=%s, Error=%v", jobData.BID, jobData.JID, recordErr)
+		}
+		return result, err
+	}
+}
+
+// DeathHandler records a batch job that will not run again as failed. Jobs
+// skipped because they expired never reach the middleware, so this is how
+// the batch learns of them; recording a dead job again changes nothing.
+func DeathHandler(store Store) concurrency.DeathHandler {
+	return func(jobData *job.SidekiqJob, result *job.JobResult) {
+		if jobData.BID == "" {
+			return
+		}
+		if err := store.RecordBatchOutcome(jobData.BID, jobData.JID, false); err != nil {
+			log.Printf("Failed to update batch: BID=%s, JID=%s, Error=%v", jobData.BID, jobData.JID, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gokiq/internal/concurrency"
+	"gokiq/internal/job"
+	"gokiq/internal/redis"
+)
+
+// outcome is a recorded call to RecordBatchOutcome
+type outcome struct {
+	bid, jid string
+	success  bool
+}
+
+// fakeStore records batches and outcomes in memory
+type fakeStore struct {
+	jobs       []*job.SidekiqJob
+	onComplete *job.SidekiqJob
+	onSuccess  *job.SidekiqJob
+	outcomes   []outcome
+}
+
+func (s *fakeStore) CreateBatch(bid, description string, jobs []*job.SidekiqJob, onComplete, onSuccess *job.SidekiqJob) error {
+	s.jobs, s.onComplete, s.onSuccess = jobs, onComplete, onSuccess
+	return nil
+}
+
+func (s *fakeStore) RecordBatchOutcome(bid, jid string, success bool) error {
+	s.outcomes = append(s.outcomes, outcome{bid, jid, success})
+	return nil
+}
+
+func (s *fakeStore) BatchStatus(bid string) (*redis.BatchStatus, error) {
+	return nil, nil
+}
+
+func TestBatch_Run(t *testing.T) {
+	store := &fakeStore{}
+	b := New("nightly import")
+	b.OnSuccess = &Callback{Class: "ImportDoneJob", Queue: "critical", Args: []interface{}{"report"}}
+	b.Add(&job.SidekiqJob{Class: "ImportRowJob"}, &job.SidekiqJob{Class: "ImportRowJob"})
+
+	if err := b.Run(store); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(store.jobs) != 2 {
+		t.Errorf("Expected 2 jobs, got %d", len(store.jobs))
+	}
+	if store.onComplete != nil {
+		t.Error("Expected no on_complete callback")
+	}
+	cb := store.onSuccess
+	if cb == nil || cb.Class != "ImportDoneJob" || cb.Queue != "critical" {
+		t.Fatalf("Unexpected on_success callback: %+v", cb)
+	}
+	if len(cb.Args) != 2 || cb.Args[0] != b.BID || cb.Args[1] != "report" {
+		t.Errorf("Expected callback args [bid report], got %v", cb.Args)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	store := &fakeStore{}
+	middleware := Middleware(store)
+
+	results := map[string]func() (*job.JobResult, error){
+		"ok":     func() (*job.JobResult, error) { return &job.JobResult{Status: "success"}, nil },
+		"failed": func() (*job.JobResult, error) { return &job.JobResult{Status: "failure"}, nil },
+		"down":   func() (*job.JobResult, error) { return nil, errors.New("connection refused") },
+		"moved":  func() (*job.JobResult, error) { return nil, concurrency.ErrRequeued },
+	}
+	for _, jid := range []string{"ok", "failed", "down", "moved"} {
+		respond := results[jid]
+		middleware(context.Background(), &job.SidekiqJob{JID: jid, BID: "bid-1"},
+			func(ctx context.Context, job *job.SidekiqJob) (*job.JobResult, error) { return respond() })
+	}
+	middleware(context.Background(), &job.SidekiqJob{JID: "loose"},
+		func(ctx context.Context, job *job.SidekiqJob) (*job.JobResult, error) { return results["ok"]() })
+
+	want := []outcome{{"bid-1", "ok", true}, {"bid-1", "failed", false}, {"bid-1", "down", false}}
+	if len(store.outcomes) != len(want) {
+		t.Fatalf("Expected %d outcomes, got %v", len(want), store.outcomes)
+	}
+	for i := range want {
+		if store.outcomes[i] != want[i] {
+			t.Errorf("Outcome %d = %+v, expected %+v", i, store.outcomes[i], want[i])
+		}
+	}
+}
+
+func TestDeathHandler(t *testing.T) {
+	store := &fakeStore{}
+	handler := DeathHandler(store)
+
+	handler(&job.SidekiqJob{JID: "stale", BID: "bid-1"}, &job.JobResult{Status: "expired"})
+	handler(&job.SidekiqJob{JID: "loose"}, &job.JobResult{Status: "failure"})
+
+	if want := []outcome{{"bid-1", "stale", false}}; len(store.outcomes) != 1 || store.outcomes[0] != want[0] {
+		t.Errorf("Expected %v, got %v", want, store.outcomes)
+	}
+}
@@ -0,0 +1,19 @@
+package batch
+
+import (
+	"gokiq/internal/job"
+	"gokiq/internal/redis"
+)
+
+// Store persists batches and their progress
+type Store interface {
+	// CreateBatch records a batch and pushes its jobs atomically
+	CreateBatch(bid, description string, jobs []*job.SidekiqJob, onComplete, onSuccess *job.SidekiqJob) error
+
+	// RecordBatchOutcome updates the batch after one of its jobs ran,
+	// pushing the callbacks exactly once
+	RecordBatchOutcome(bid, jid string, success bool) error
+
+	// BatchStatus returns the progress of a batch, or nil if it does not exist
+	BatchStatus(bid string) (*redis.BatchStatus, error)
+}
@@ -0,0 +1,132 @@
+package blob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"gokiq/internal/concurrency"
+	"gokiq/internal/config"
+	"gokiq/internal/job"
+)
+
+// missingArgsErrorClass is recorded when the blob holding a job's args is gone
+const missingArgsErrorClass = "Gokiq::MissingArgs"
+
+// NewStore creates the store selected by cfg.Backend, or returns nil when
+// offloading is disabled
+func NewStore(cfg config.BlobConfig) (Store, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "local":
+		return NewLocalStore(cfg.Local.Dir)
+	case "s3":
+		return NewS3Store(cfg.S3), nil
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", cfg.Backend)
+	}
+}
+
+// Offloader moves job args larger than a threshold into a store
+type Offloader struct {
+	store     Store
+	threshold int
+}
+
+// NewOffloader creates an offloader for args whose JSON exceeds threshold bytes
+func NewOffloader(store Store, threshold int) *Offloader {
+	return &Offloader{store: store, threshold: threshold}
+}
+
+// Offload stores the args of jobData under a key derived from its JID and
+// replaces them with a reference, when they are larger than the threshold.
+// The JID must already be set.
+func (o *Offloader) Offload(jobData *job.SidekiqJob) error {
+	if jobData.ArgsRef != "" {
+		return nil
+	}
+
+	data, err := json.Marshal(jobData.Args)
+	if err != nil {
+		return fmt.Errorf("failed to marshal args: %w", err)
+	}
+	if len(data) <= o.threshold {
+		return nil
+	}
+
+	key := jobData.JID + ".json"
+	if err := o.store.Put(key, data); err != nil {
+		return fmt.Errorf("failed to offload args of %s: %w", jobData.JID, err)
+	}
+	jobData.ArgsRef = key
+	jobData.Args = []interface{}{}
+	return nil
+}
+
+// Resolve returns the args stored under ref
+func Resolve(store Store, ref string) ([]interface{}, error) {
+	data, err := store.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var args []interface{}
+	if err := json.Unmarshal(data, &args); err != nil {
+		return nil, fmt.Errorf("failed to decode args in blob %s: %w", ref, err)
+	}
+	return args, nil
+}
+
+// Middleware is server middleware that resolves offloaded args before a job
+// runs, and deletes the blob once the job succeeded or was discarded. Args
+// are resolved on a copy so retries and the dead set keep the reference. A
+// job whose blob is missing can never run, so it goes to the dead set.
+func Middleware(store Store) concurrency.Middleware {
+	return func(ctx context.Context, jobData *job.SidekiqJob, next concurrency.Next) (*job.JobResult, error) {
+		if jobData.ArgsRef == "" {
+			return next(ctx, jobData)
+		}
+
+		args, err := Resolve(store, jobData.ArgsRef)
+		if errors.Is(err, ErrNotFound) {
+			retryable := false
+			return &job.JobResult{
+				Status:       "failure",
+				ErrorClass:   missingArgsErrorClass,
+				ErrorMessage: err.Error(),
+				Retryable:    &retryable,
+			}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		resolved := *jobData
+		resolved.Args = args
+		resolved.ArgsRef = ""
+
+		result, err := next(ctx, &resolved)
+		if err == nil && result != nil && (result.Status == "success" || result.Discard) {
+			if deleteErr := store.Delete(jobData.ArgsRef); deleteErr != nil {
+				log.Printf("Failed to delete offloaded args: JID=%s, Ref=%s, Error=%v", jobData.JID, jobData.ArgsRef, deleteErr)
+			}
+		}
+		return result, err
+	}
+}
+
+// DropHandler deletes the blob of an expired job that is dropped rather than
+// kept, as nothing will resolve it again
+func DropHandler(store Store) concurrency.DropHandler {
+	return func(jobData *job.SidekiqJob) {
+		if jobData.ArgsRef == "" {
+			return
+		}
+		if err := store.Delete(jobData.ArgsRef); err != nil {
+			log.Printf("Failed to delete offloaded args: JID=%s, Ref=%s, Error=%v", jobData.JID, jobData.ArgsRef, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gokiq/internal/job"
+)
+
+// memoryStore keeps blobs in a map
+type memoryStore struct {
+	blobs   map[string][]byte
+	getErr  error
+	deleted []string
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{blobs: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Put(key string, data []byte) error {
+	s.blobs[key] = data
+	return nil
+}
+
+func (s *memoryStore) Get(key string) ([]byte, error) {
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("failed to read blob %s: %w", key, ErrNotFound)
+	}
+	return data, nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	s.deleted = append(s.deleted, key)
+	delete(s.blobs, key)
+	return nil
+}
+
+func TestOffloader_Offload(t *testing.T) {
+	store := newMemoryStore()
+	offloader := NewOffloader(store, 16)
+
+	small := &job.SidekiqJob{JID: "jid-small", Args: []interface{}{"tiny"}}
+	if err := offloader.Offload(small); err != nil {
+		t.Fatalf("Offload failed: %v", err)
+	}
+	if small.ArgsRef != "" || len(small.Args) != 1 {
+		t.Errorf("Expected small args to stay inline, got %+v", small)
+	}
+
+	large := &job.SidekiqJob{JID: "jid-large", Args: []interface{}{"a string longer than sixteen bytes"}}
+	if err := offloader.Offload(large); err != nil {
+		t.Fatalf("Offload failed: %v", err)
+	}
+	if large.ArgsRef != "jid-large.json" || len(large.Args) != 0 {
+		t.Fatalf("Expected large args to be offloaded, got %+v", large)
+	}
+	if got := string(store.blobs["jid-large.json"]); got != `["a string longer than sixteen bytes"]` {
+		t.Errorf("Unexpected blob: %s", got)
+	}
+}
+
+func TestMiddleware_ResolvesArgs(t *testing.T) {
+	store := newMemoryStore()
+	store.blobs["jid-1.json"] = []byte(`[1,"two"]`)
+	jobData := &job.SidekiqJob{JID: "jid-1", Class: "ImportJob", Args: []interface{}{}, ArgsRef: "jid-1.json"}
+
+	var executed *job.SidekiqJob
+	next := func(ctx context.Context, j *job.SidekiqJob) (*job.JobResult, error) {
+		executed = j
+		return &job.JobResult{Status: "failure"}, nil
+	}
+
+	result, err := Middleware(store)(context.Background(), jobData, next)
+	if err != nil || result.Status != "failure" {
+		t.Fatalf("Unexpected result %+v, %v", result, err)
+	}
+	if len(executed.Args) != 2 || executed.Args[1] != "two" || executed.ArgsRef != "" {
+		t.Errorf("Expected resolved args without the reference, got %+v", executed)
+	}
+	if len(jobData.Args) != 0 || jobData.ArgsRef != "jid-1.json" {
+		t.Errorf("Expected the job to keep its reference for retries, got %+v", jobData)
+	}
+	if len(store.deleted) != 0 {
+		t.Errorf("Expected the blob to be kept for a retry, deleted %v", store.deleted)
+	}
+
+	next = func(ctx context.Context, j *job.SidekiqJob) (*job.JobResult, error) {
+		return &job.JobResult{Status: "success"}, nil
+	}
+	if _, err := Middleware(store)(context.Background(), jobData, next); err != nil {
+		t.Fatalf("Middleware failed: %v", err)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != "jid-1.json" {
+		t.Errorf("Expected the blob to be deleted after success, deleted %v", store.deleted)
+	}
+}
+
+func TestMiddleware_MissingBlob(t *testing.T) {
+	store := newMemoryStore()
+	jobData := &job.SidekiqJob{JID: "jid-1", Class: "ImportJob", ArgsRef: "jid-1.json"}
+	next := func(ctx context.Context, j *job.SidekiqJob) (*job.JobResult, error) {
+		t.Fatal("Job with a missing blob must not run")
+		return nil, nil
+	}
+
+	result, err := Middleware(store)(context.Background(), jobData, next)
+	if err != nil {
+		t.Fatalf("Middleware failed: %v", err)
+	}
+	if result.Status != "failure" || result.ErrorClass != missingArgsErrorClass || result.Retryable == nil || *result.Retryable {
+		t.Errorf("Expected a non-retryable failure, got %+v", result)
+	}
+
+	// Other store errors may be transient, so the job is retried
+	store.getErr = errors.New("connection refused")
+	if _, err := Middleware(store)(context.Background(), jobData, next); err == nil {
+		t.Error("Expected an error when the store is unavailable")
+	}
+}
+
+func TestDropHandler(t *testing.T) {
+	store := newMemoryStore()
+	store.blobs["jid-1.json"] = []byte(`[1]`)
+
+	DropHandler(store)(&job.SidekiqJob{JID: "jid-1", ArgsRef: "jid-1.json"})
+	DropHandler(store)(&job.SidekiqJob{JID: "jid-2", Args: []interface{}{1}})
+
+	if len(store.deleted) != 1 || store.deleted[0] != "jid-1.json" {
+		t.Errorf("Expected only the referenced blob to be deleted, deleted %v", store.deleted)
+	}
+}
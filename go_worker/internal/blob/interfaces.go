@@ -0,0 +1,18 @@
+package blob
+
+import "errors"
+
+// ErrNotFound is returned by Get when no blob is stored under the key
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs outside Redis
+type Store interface {
+	// Put stores data under key, replacing any existing blob
+	Put(key string, data []byte) error
+
+	// Get returns the blob stored under key, or ErrNotFound
+	Get(key string) ([]byte, error)
+
+	// Delete removes the blob stored under key; a missing blob is not an error
+	Delete(key string) error
+}
@@ -0,0 +1,37 @@
+package blob
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+
+	if err := store.Put("args/jid-1.json", []byte(`[1]`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	data, err := store.Get("args/jid-1.json")
+	if err != nil || string(data) != `[1]` {
+		t.Fatalf("Get() = %q, %v", data, err)
+	}
+
+	if err := store.Delete("args/jid-1.json"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get("args/jid-1.json"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Delete("args/jid-1.json"); err != nil {
+		t.Errorf("Deleting a missing blob should succeed, got %v", err)
+	}
+
+	for _, key := range []string{"../escape.json", "/etc/passwd", ""} {
+		if _, err := store.Get(key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected key %q to be rejected, got %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gokiq/internal/config"
+)
+
+const (
+	// minSamples is the number of jobs a window needs before the limit is adjusted
+	minSamples = 10
+
+	// maxDecisions is the number of recent decisions kept for Status
+	maxDecisions = 100
+
+	// baselineDrift is the fraction of the gap the baseline latency moves up
+	// per window, so a lasting slowdown eventually becomes the new normal
+	baselineDrift = 0.05
+)
+
+// Decision records a single change to the concurrency limit
+type Decision struct {
+	Time       time.Time `json:"time"`
+	Previous   int       `json:"previous"`
+	Limit      int       `json:"limit"`
+	Samples    int       `json:"samples"`
+	ErrorRate  float64   `json:"error_rate"`
+	LatencyMS  float64   `json:"latency_ms"`
+	BaselineMS float64   `json:"baseline_ms"`
+	Reason     string    `json:"reason"`
+}
+
+// AdaptiveStatus describes the controller's current state and recent decisions
+type AdaptiveStatus struct {
+	Limit      int        `json:"limit"`
+	Min        int        `json:"min"`
+	Max        int        `json:"max"`
+	Active     int        `json:"active"`
+	Waiting    int        `json:"waiting"`
+	BaselineMS float64    `json:"baseline_ms"`
+	Decisions  []Decision `json:"decisions"`
+}
+
+// AdaptiveController adjusts a processor's concurrency using AIMD: the limit
+// grows by one while the processor is saturated and healthy, and is cut
+// multiplicatively when sidecar errors or latency rise above their thresholds.
+// Only errors returned by ExecuteJob count; jobs that fail inside Rails do not.
+type AdaptiveController struct {
+	processor *ConcurrentProcessor
+	cfg       config.AdaptiveConfig
+
+	mu        sync.Mutex
+	samples   int
+	errors    int
+	latency   time.Duration
+	saturated bool
+	baseline  time.Duration
+	decisions []Decision
+}
+
+// NewAdaptiveController creates a controller for processor and registers it
+// as the processor's job observer. The current limit is clamped to [Min, Max].
+func NewAdaptiveController(processor *ConcurrentProcessor, cfg config.AdaptiveConfig) *AdaptiveController {
+	ac := &AdaptiveController{
+		processor: processor,
+		cfg:       cfg,
+	}
+	processor.SetObserver(ac)
+
+	if limit := processor.Capacity(); ac.clamp(limit) != limit {
+		processor.SetConcurrency(ac.clamp(limit))
+	}
+	return ac
+}
+
+// ObserveJob records a job outcome for the current window
+func (ac *AdaptiveController) ObserveJob(duration time.Duration, err error) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.samples++
+	ac.latency += duration
+	if err != nil {
+		ac.errors++
+	}
+	if ac.processor.WaitingJobs() > 0 || ac.processor.ActiveJobs() >= ac.processor.Capacity() {
+		ac.saturated = true
+	}
+}
+
+// Run adjusts the limit every configured interval until ctx is cancelled
+func (ac *AdaptiveController) Run(ctx context.Context) {
+	ticker := time.NewTicker(ac.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ac.Adjust()
+		}
+	}
+}
+
+// Adjust evaluates the current window and resizes the processor if needed.
+// It returns the decision made, or nil when the limit is unchanged.
+func (ac *AdaptiveController) Adjust() *Decision {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	samples, errors, total, saturated := ac.samples, ac.errors, ac.latency, ac.saturated
+	ac.samples, ac.errors, ac.latency, ac.saturated = 0, 0, 0, false
+
+	current := ac.processor.Capacity()
+	decision := Decision{
+		Time:     time.Now(),
+		Previous: current,
+		Samples:  samples,
+	}
+
+	if samples < minSamples {
+		// Too little traffic to judge, but keep the limit within bounds
+		decision.Limit = ac.clamp(current)
+		decision.Reason = "outside configured bounds"
+		return ac.apply(decision)
+	}
+
+	avg := total / time.Duration(samples)
+	errorRate := float64(errors) / float64(samples)
+	baseline := ac.baseline
+	decision.ErrorRate = errorRate
+	decision.LatencyMS = milliseconds(avg)
+	decision.BaselineMS = milliseconds(baseline)
+
+	switch {
+	case errorRate > ac.cfg.MaxErrorRate:
+		decision.Limit = ac.decrease(current)
+		decision.Reason = fmt.Sprintf("error rate %.2f above %.2f", errorRate, ac.cfg.MaxErrorRate)
+	case baseline > 0 && float64(avg) > float64(baseline)*ac.cfg.LatencyTolerance:
+		decision.Limit = ac.decrease(current)
+		decision.Reason = fmt.Sprintf("latency %v above %.1fx baseline %v", avg, ac.cfg.LatencyTolerance, baseline)
+	case saturated:
+		decision.Limit = ac.clamp(current + 1)
+		decision.Reason = "saturated with healthy latency"
+	default:
+		decision.Limit = ac.clamp(current)
+		decision.Reason = "outside configured bounds"
+	}
+
+	// Track the lowest latency seen, drifting slowly towards the current one
+	if baseline == 0 || avg < baseline {
+		ac.baseline = avg
+	} else {
+		ac.baseline += time.Duration(float64(avg-baseline) * baselineDrift)
+	}
+
+	return ac.apply(decision)
+}
+
+// SetBounds changes the range the limit is kept within, clamping the current
+// limit to it right away
+func (ac *AdaptiveController) SetBounds(min, max int) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.cfg.Min, ac.cfg.Max = min, max
+
+	current := ac.processor.Capacity()
+	ac.apply(Decision{
+		Time:     time.Now(),
+		Previous: current,
+		Limit:    ac.clamp(current),
+		Reason:   "outside configured bounds",
+	})
+}
+
+// Status returns the current limit, bounds and recent decisions
+func (ac *AdaptiveController) Status() AdaptiveStatus {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	decisions := make([]Decision, len(ac.decisions))
+	copy(decisions, ac.decisions)
+
+	return AdaptiveStatus{
+		Limit:      ac.processor.Capacity(),
+		Min:        ac.cfg.Min,
+		Max:        ac.cfg.Max,
+		Active:     ac.processor.ActiveJobs(),
+		Waiting:    ac.processor.WaitingJobs(),
+		BaselineMS: milliseconds(ac.baseline),
+		Decisions:  decisions,
+	}
+}
+
+// apply resizes the processor and records the decision if the limit changed
+func (ac *AdaptiveController) apply(decision Decision) *Decision {
+	if decision.Limit == decision.Previous {
+		return nil
+	}
+
+	ac.processor.SetConcurrency(decision.Limit)
+	log.Printf("Adaptive concurrency: %d -> %d (%s)", decision.Previous, decision.Limit, decision.Reason)
+
+	ac.decisions = append(ac.decisions, decision)
+	if len(ac.decisions) > maxDecisions {
+		ac.decisions = ac.decisions[len(ac.decisions)-maxDecisions:]
+	}
+	return &decision
+}
+
+func (ac *AdaptiveController) decrease(limit int) int {
+	next := int(float64(limit) * ac.cfg.Backoff)
+	if next == limit {
+		next--
+	}
+	return ac.clamp(next)
+}
+
+func (ac *AdaptiveController) clamp(limit int) int {
+	if limit < ac.cfg.Min {
+		return ac.cfg.Min
+	}
+	if limit > ac.cfg.Max {
+		return ac.cfg.Max
+	}
+	return limit
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
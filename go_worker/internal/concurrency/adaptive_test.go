@@ -0,0 +1,139 @@
+package concurrency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gokiq/internal/config"
+)
+
+func testAdaptiveConfig() config.AdaptiveConfig {
+	return config.AdaptiveConfig{
+		Enabled:          true,
+		Min:              2,
+		Max:              20,
+		Interval:         time.Second,
+		LatencyTolerance: 2,
+		MaxErrorRate:     0.1,
+		Backoff:          0.5,
+	}
+}
+
+// observe records n jobs of the given latency, the first failures of which return an error
+func observe(ac *AdaptiveController, n, failures int, latency time.Duration) {
+	for i := 0; i < n; i++ {
+		var err error
+		if i < failures {
+			err = errors.New("sidecar unavailable")
+		}
+		ac.ObserveJob(latency, err)
+	}
+}
+
+func TestNewAdaptiveController_ClampsLimit(t *testing.T) {
+	processor := NewConcurrentProcessor(50, NewMockJobExecutor())
+	defer processor.Shutdown(time.Second)
+
+	NewAdaptiveController(processor, testAdaptiveConfig())
+	if processor.Capacity() != 20 {
+		t.Errorf("Expected limit clamped to max 20, got %d", processor.Capacity())
+	}
+}
+
+func TestAdaptiveController_SetBounds(t *testing.T) {
+	processor := NewConcurrentProcessor(10, NewMockJobExecutor())
+	defer processor.Shutdown(time.Second)
+
+	ac := NewAdaptiveController(processor, testAdaptiveConfig())
+	ac.SetBounds(2, 6)
+	if processor.Capacity() != 6 {
+		t.Errorf("Expected limit clamped to new max 6, got %d", processor.Capacity())
+	}
+
+	// Raising the bounds leaves growing the limit to the controller
+	ac.SetBounds(2, 40)
+	if status := ac.Status(); status.Limit != 6 || status.Max != 40 {
+		t.Errorf("Expected limit 6 within new bounds, got %+v", status)
+	}
+}
+
+func TestAdaptiveController_Adjust(t *testing.T) {
+	processor := NewConcurrentProcessor(8, NewMockJobExecutor())
+	defer processor.Shutdown(time.Second)
+	ac := NewAdaptiveController(processor, testAdaptiveConfig())
+
+	// Too few samples: no decision
+	observe(ac, 3, 0, 10*time.Millisecond)
+	if d := ac.Adjust(); d != nil {
+		t.Fatalf("Expected no decision with few samples, got %+v", d)
+	}
+
+	// Healthy but idle: establishes the baseline without changing the limit
+	observe(ac, 10, 0, 10*time.Millisecond)
+	if d := ac.Adjust(); d != nil {
+		t.Fatalf("Expected no decision when not saturated, got %+v", d)
+	}
+
+	// Healthy and saturated: additive increase
+	observe(ac, 10, 0, 10*time.Millisecond)
+	ac.mu.Lock()
+	ac.saturated = true
+	ac.mu.Unlock()
+	if d := ac.Adjust(); d == nil || d.Limit != 9 {
+		t.Fatalf("Expected limit to grow to 9, got %+v", d)
+	}
+
+	// Latency above tolerance: multiplicative decrease
+	observe(ac, 10, 0, 50*time.Millisecond)
+	d := ac.Adjust()
+	if d == nil || d.Limit != 4 {
+		t.Fatalf("Expected limit to back off to 4, got %+v", d)
+	}
+	if d.LatencyMS != 50 || d.BaselineMS != 10 {
+		t.Errorf("Expected latency 50ms against baseline 10ms, got %+v", d)
+	}
+
+	// Error rate above threshold: decrease, never below min
+	observe(ac, 10, 5, 10*time.Millisecond)
+	if d := ac.Adjust(); d == nil || d.Limit != 2 {
+		t.Fatalf("Expected limit to back off to min 2, got %+v", d)
+	}
+	observe(ac, 10, 5, 10*time.Millisecond)
+	if d := ac.Adjust(); d != nil {
+		t.Fatalf("Expected no decision at min, got %+v", d)
+	}
+
+	status := ac.Status()
+	if status.Limit != 2 || processor.Capacity() != 2 {
+		t.Errorf("Expected limit 2, got status %d and processor %d", status.Limit, processor.Capacity())
+	}
+	if len(status.Decisions) != 3 {
+		t.Errorf("Expected 3 recorded decisions, got %d", len(status.Decisions))
+	}
+}
+
+func TestAdaptiveController_ObservesProcessor(t *testing.T) {
+	executor := NewMockJobExecutor()
+	executor.SetExecutionTime(time.Millisecond)
+	processor := NewConcurrentProcessor(1, executor)
+	defer processor.Shutdown(time.Second)
+	ac := NewAdaptiveController(processor, testAdaptiveConfig())
+
+	for i := 0; i < 3; i++ {
+		if err := processor.ProcessJob(createTestJob("jid", "TestJob")); err != nil {
+			t.Fatalf("ProcessJob failed: %v", err)
+		}
+	}
+	waitFor(t, func() bool { return processor.ProcessedCount() == 3 })
+
+	ac.mu.Lock()
+	samples, saturated := ac.samples, ac.saturated
+	ac.mu.Unlock()
+	if samples != 3 {
+		t.Errorf("Expected 3 observed jobs, got %d", samples)
+	}
+	if !saturated {
+		t.Error("Expected a single-slot processor to be reported as saturated")
+	}
+}
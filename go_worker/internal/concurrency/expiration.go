@@ -0,0 +1,70 @@
+package concurrency
+
+import (
+	"fmt"
+	"time"
+
+	"gokiq/internal/config"
+	"gokiq/internal/job"
+)
+
+// expiredStatus is the result status of a job skipped because it expired
+const expiredStatus = "expired"
+
+// ExpirationPolicy controls when jobs are too old to be worth running
+type ExpirationPolicy struct {
+	TTLs        map[string]time.Duration
+	KeepExpired bool
+}
+
+// NewExpirationPolicy creates an expiration policy from configuration
+func NewExpirationPolicy(cfg config.ExpirationConfig) ExpirationPolicy {
+	return ExpirationPolicy{
+		TTLs:        cfg.TTLs,
+		KeepExpired: cfg.KeepExpired,
+	}
+}
+
+// ExpiresAt returns when a job expires, or the zero time if it never does.
+// The payload's expires_at wins, then its expires_in and then the TTL of its
+// class, both counted from created_at (enqueued_at for payloads without it).
+func (p ExpirationPolicy) ExpiresAt(jobData *job.SidekiqJob) time.Time {
+	if jobData.ExpiresAt > 0 {
+		return unixTime(jobData.ExpiresAt)
+	}
+
+	created := jobData.CreatedAt
+	if created == 0 {
+		created = jobData.EnqueuedAt
+	}
+	if created == 0 {
+		return time.Time{}
+	}
+
+	if jobData.ExpiresIn > 0 {
+		return unixTime(created).Add(time.Duration(jobData.ExpiresIn * float64(time.Second)))
+	}
+	if ttl, ok := p.TTLs[jobData.Class]; ok {
+		return unixTime(created).Add(ttl)
+	}
+	return time.Time{}
+}
+
+// Expired reports whether a job has expired at now
+func (p ExpirationPolicy) Expired(jobData *job.SidekiqJob, now time.Time) bool {
+	expiresAt := p.ExpiresAt(jobData)
+	return !expiresAt.IsZero() && now.After(expiresAt)
+}
+
+// expiredResult is the result recorded for a job skipped because it expired
+func (p ExpirationPolicy) expiredResult(jobData *job.SidekiqJob) *job.JobResult {
+	return &job.JobResult{
+		Status:       expiredStatus,
+		ErrorMessage: fmt.Sprintf("job expired at %s", p.ExpiresAt(jobData).UTC().Format(time.RFC3339)),
+	}
+}
+
+// unixTime converts a Sidekiq timestamp in fractional seconds
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
@@ -0,0 +1,41 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+
+	"gokiq/internal/job"
+)
+
+func TestExpirationPolicy_ExpiresAt(t *testing.T) {
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	stamp := float64(created.Unix())
+	policy := ExpirationPolicy{TTLs: map[string]time.Duration{"NotifyJob": time.Hour}}
+
+	tests := []struct {
+		name string
+		job  *job.SidekiqJob
+		want time.Time
+	}{
+		{"no expiry", &job.SidekiqJob{Class: "ChargeJob", CreatedAt: stamp}, time.Time{}},
+		{"class ttl", &job.SidekiqJob{Class: "NotifyJob", CreatedAt: stamp}, created.Add(time.Hour)},
+		{"expires_in overrides class ttl", &job.SidekiqJob{Class: "NotifyJob", CreatedAt: stamp, ExpiresIn: 60}, created.Add(time.Minute)},
+		{"expires_at wins", &job.SidekiqJob{Class: "NotifyJob", CreatedAt: stamp, ExpiresIn: 60, ExpiresAt: stamp + 7200}, created.Add(2 * time.Hour)},
+		{"enqueued_at without created_at", &job.SidekiqJob{Class: "NotifyJob", EnqueuedAt: stamp}, created.Add(time.Hour)},
+		{"no timestamps", &job.SidekiqJob{Class: "NotifyJob"}, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		if got := policy.ExpiresAt(tt.job); !got.Equal(tt.want) {
+			t.Errorf("%s: ExpiresAt() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	expiring := &job.SidekiqJob{Class: "NotifyJob", CreatedAt: stamp}
+	if policy.Expired(expiring, created.Add(59*time.Minute)) {
+		t.Error("Expected job to be live before its TTL")
+	}
+	if !policy.Expired(expiring, created.Add(61*time.Minute)) {
+		t.Error("Expected job to be expired after its TTL")
+	}
+}
@@ -0,0 +1,159 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"gokiq/internal/job"
+)
+
+// Next invokes the rest of the middleware chain and finally the executor
+type Next func(ctx context.Context, job *job.SidekiqJob) (*job.JobResult, error)
+
+// Middleware wraps job execution, like Sidekiq server middleware. It may
+// inspect or modify the job, call next zero or one times, and inspect or
+// replace the result.
+type Middleware func(ctx context.Context, job *job.SidekiqJob, next Next) (*job.JobResult, error)
+
+// namedMiddleware is a chain entry
+type namedMiddleware struct {
+	name       string
+	middleware Middleware
+}
+
+// Chain is an ordered list of named middleware
+type Chain struct {
+	entries []namedMiddleware
+	mu      sync.RWMutex
+}
+
+// NewChain creates an empty middleware chain
+func NewChain() *Chain {
+	return &Chain{}
+}
+
+// Add appends middleware to the end of the chain, replacing any entry with the same name
+func (c *Chain) Add(name string, middleware Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(name)
+	c.entries = append(c.entries, namedMiddleware{name, middleware})
+}
+
+// Prepend inserts middleware at the start of the chain, replacing any entry with the same name
+func (c *Chain) Prepend(name string, middleware Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(name)
+	c.entries = append([]namedMiddleware{{name, middleware}}, c.entries...)
+}
+
+// InsertBefore inserts middleware immediately before the entry named before
+func (c *Chain) InsertBefore(before, name string, middleware Middleware) error {
+	return c.insert(before, name, middleware, 0)
+}
+
+// InsertAfter inserts middleware immediately after the entry named after
+func (c *Chain) InsertAfter(after, name string, middleware Middleware) error {
+	return c.insert(after, name, middleware, 1)
+}
+
+// Remove deletes the named middleware, reporting whether it was present
+func (c *Chain) Remove(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.remove(name)
+}
+
+// Names returns the middleware names in execution order
+func (c *Chain) Names() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, len(c.entries))
+	for i, entry := range c.entries {
+		names[i] = entry.name
+	}
+	return names
+}
+
+// Reorder keeps only the named middleware, in the given order
+func (c *Chain) Reorder(names []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make([]namedMiddleware, 0, len(names))
+	for _, name := range names {
+		i := c.index(name)
+		if i < 0 {
+			return fmt.Errorf("unknown middleware %q", name)
+		}
+		entries = append(entries, c.entries[i])
+	}
+	c.entries = entries
+	return nil
+}
+
+// Invoke runs job through the chain, calling final after the last middleware
+func (c *Chain) Invoke(ctx context.Context, jobData *job.SidekiqJob, final Next) (*job.JobResult, error) {
+	c.mu.RLock()
+	entries := c.entries
+	c.mu.RUnlock()
+
+	next := final
+	for i := len(entries) - 1; i >= 0; i-- {
+		middleware, inner := entries[i].middleware, next
+		next = func(ctx context.Context, job *job.SidekiqJob) (*job.JobResult, error) {
+			return middleware(ctx, job, inner)
+		}
+	}
+	return next(ctx, jobData)
+}
+
+func (c *Chain) insert(anchor, name string, middleware Middleware, offset int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if name == anchor {
+		return fmt.Errorf("middleware %q cannot be inserted relative to itself", name)
+	}
+	c.remove(name)
+	i := c.index(anchor)
+	if i < 0 {
+		return fmt.Errorf("unknown middleware %q", anchor)
+	}
+
+	i += offset
+	c.entries = append(c.entries[:i], append([]namedMiddleware{{name, middleware}}, c.entries[i:]...)...)
+	return nil
+}
+
+func (c *Chain) remove(name string) bool {
+	i := c.index(name)
+	if i < 0 {
+		return false
+	}
+	c.entries = append(c.entries[:i:i], c.entries[i+1:]...)
+	return true
+}
+
+func (c *Chain) index(name string) int {
+	for i, entry := range c.entries {
+		if entry.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Recover is middleware that turns a panic further down the chain into an error
+func Recover(ctx context.Context, job *job.SidekiqJob, next Next) (result *job.JobResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return next(ctx, job)
+}
@@ -0,0 +1,128 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gokiq/internal/job"
+)
+
+// recording returns middleware that appends its name to calls around next
+func recording(name string, calls *[]string) Middleware {
+	return func(ctx context.Context, j *job.SidekiqJob, next Next) (*job.JobResult, error) {
+		*calls = append(*calls, name)
+		result, err := next(ctx, j)
+		*calls = append(*calls, "/"+name)
+		return result, err
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	chain := NewChain()
+	chain.Add("b", recording("b", &calls))
+	chain.Prepend("a", recording("a", &calls))
+	chain.Add("d", recording("d", &calls))
+	if err := chain.InsertBefore("d", "c", recording("c", &calls)); err != nil {
+		t.Fatalf("InsertBefore failed: %v", err)
+	}
+	if err := chain.InsertAfter("d", "e", recording("e", &calls)); err != nil {
+		t.Fatalf("InsertAfter failed: %v", err)
+	}
+
+	if want := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(chain.Names(), want) {
+		t.Fatalf("Names() = %v, want %v", chain.Names(), want)
+	}
+
+	// Re-adding an existing name moves it
+	chain.Add("a", recording("a", &calls))
+	if !chain.Remove("e") || chain.Remove("missing") {
+		t.Error("Remove should report whether the middleware was present")
+	}
+
+	_, err := chain.Invoke(context.Background(), &job.SidekiqJob{JID: "jid"},
+		func(ctx context.Context, j *job.SidekiqJob) (*job.JobResult, error) {
+			calls = append(calls, "execute")
+			return &job.JobResult{Status: "success"}, nil
+		})
+	if err != nil {
+		t.Fatalf("Invoke failed: %v", err)
+	}
+
+	want := []string{"b", "c", "d", "a", "execute", "/a", "/d", "/c", "/b"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	if err := chain.InsertAfter("missing", "x", recording("x", &calls)); err == nil {
+		t.Error("Expected error inserting relative to unknown middleware")
+	}
+}
+
+func TestChain_Reorder(t *testing.T) {
+	var calls []string
+	chain := NewChain()
+	chain.Add("a", recording("a", &calls))
+	chain.Add("b", recording("b", &calls))
+	chain.Add("c", recording("c", &calls))
+
+	if err := chain.Reorder([]string{"c", "a"}); err != nil {
+		t.Fatalf("Reorder failed: %v", err)
+	}
+	if want := []string{"c", "a"}; !reflect.DeepEqual(chain.Names(), want) {
+		t.Errorf("Names() = %v, want %v", chain.Names(), want)
+	}
+
+	if err := chain.Reorder([]string{"missing"}); err == nil {
+		t.Error("Expected error for unknown middleware")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	_, err := Recover(context.Background(), &job.SidekiqJob{},
+		func(ctx context.Context, j *job.SidekiqJob) (*job.JobResult, error) {
+			panic("boom")
+		})
+	if err == nil || !strings.Contains(err.Error(), "panic: boom") {
+		t.Errorf("Expected panic to be returned as error, got %v", err)
+	}
+}
+
+func TestConcurrentProcessor_Middleware(t *testing.T) {
+	executor := NewMockJobExecutor()
+	executor.SetExecutionTime(time.Millisecond)
+	processor := NewConcurrentProcessor(1, executor)
+
+	// Middleware can transform the payload before execution
+	processor.Middleware().Add("tenant", func(ctx context.Context, j *job.SidekiqJob, next Next) (*job.JobResult, error) {
+		j.Args = append([]interface{}{"tenant-42"}, j.Args...)
+		return next(ctx, j)
+	})
+
+	// ...or stop it from executing at all
+	processor.Middleware().Add("auth", func(ctx context.Context, j *job.SidekiqJob, next Next) (*job.JobResult, error) {
+		if j.Class == "ForbiddenJob" {
+			return nil, errors.New("forbidden")
+		}
+		return next(ctx, j)
+	})
+
+	processor.ProcessJob(createTestJob("allowed", "TestJob"))
+	processor.ProcessJob(createTestJob("blocked", "ForbiddenJob"))
+	processor.Shutdown(time.Second)
+
+	executed := executor.GetExecutedJobs()
+	if len(executed) != 1 || executed[0].JID != "allowed" {
+		t.Fatalf("Expected only the allowed job to execute, got %d jobs", len(executed))
+	}
+	if executed[0].Args[0] != "tenant-42" {
+		t.Errorf("Expected middleware to prepend tenant arg, got %v", executed[0].Args)
+	}
+	if processor.FailedCount() != 1 {
+		t.Errorf("Expected the blocked job to count as failed, got %d", processor.FailedCount())
+	}
+}
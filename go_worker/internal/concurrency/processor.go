@@ -2,12 +2,16 @@ package concurrency
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gokiq/internal/job"
+	"gokiq/internal/redis"
 )
 
 // JobExecutor defines the interface for executing jobs
@@ -15,26 +19,96 @@ type JobExecutor interface {
 	ExecuteJob(job *job.SidekiqJob) (*job.JobResult, error)
 }
 
+// ContextJobExecutor is implemented by executors that accept the context
+// passed down the middleware chain
+type ContextJobExecutor interface {
+	ExecuteJobContext(ctx context.Context, job *job.SidekiqJob) (*job.JobResult, error)
+}
+
+const (
+	// sidecarErrorClass is recorded when the sidecar could not be reached or answered with an error status
+	sidecarErrorClass = "Gokiq::SidecarError"
+
+	// defaultErrorClass is recorded when a failed result names no exception class
+	defaultErrorClass = "StandardError"
+
+	// maxBacktraceLines caps the backtrace stored in the job payload
+	maxBacktraceLines = 50
+)
+
+// ErrRequeued is returned down the middleware chain for a job whose executor
+// returned after a timed out shutdown had already pushed it back onto its
+// queue. Its outcome belongs to the requeued copy, so middleware should not
+// record it.
+var ErrRequeued = errors.New("job was requeued during shutdown")
+
+// RunningJob is a job currently being executed
+type RunningJob struct {
+	Job       *job.SidekiqJob
+	StartedAt time.Time
+}
+
+// JobObserver is notified of the outcome of every executed job
+type JobObserver interface {
+	// ObserveJob records how long ExecuteJob took and whether it returned an error
+	ObserveJob(duration time.Duration, err error)
+}
+
+// DeathHandler is called when a job will not run again because it was moved
+// to the dead set or discarded. result is the last failure, if known.
+type DeathHandler func(job *job.SidekiqJob, result *job.JobResult)
+
+// DropHandler is called when an expired job is dropped without being kept in
+// the expired set, so whatever it references can be released
+type DropHandler func(job *job.SidekiqJob)
+
 // ConcurrentProcessor manages concurrent job processing with semaphore control
 type ConcurrentProcessor struct {
-	semaphore *Semaphore
-	executor  JobExecutor
-	ctx       context.Context
-	cancel    context.CancelFunc
-	wg        sync.WaitGroup
-	mu        sync.RWMutex
-	running   bool
+	semaphore  *Semaphore
+	executor   JobExecutor
+	middleware *Chain
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	mu         sync.RWMutex
+	running    bool
+	processed  atomic.Int64
+	failed     atomic.Int64
+	expired    atomic.Int64
+	observer   JobObserver
+
+	// inFlight tracks executing jobs by submission sequence number
+	inFlight map[uint64]RunningJob
+	nextID   uint64
+
+	// requeued holds the JIDs of running jobs pushed back by a timed out
+	// shutdown, and returned those whose executor returned first
+	requeued map[string]bool
+	returned map[string]bool
+
+	// store persists retries and jobs interrupted by shutdown. It is nil until
+	// retries are enabled, in which case failures are only logged.
+	store       redis.RedisClient
+	retryPolicy RetryPolicy
+	expiration  ExpirationPolicy
+
+	deathHandlers []DeathHandler
+	dropHandlers  []DropHandler
 }
 
 // NewConcurrentProcessor creates a new concurrent processor
 func NewConcurrentProcessor(concurrency int, executor JobExecutor) *ConcurrentProcessor {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &ConcurrentProcessor{
-		semaphore: NewSemaphore(concurrency),
-		executor:  executor,
-		ctx:       ctx,
-		cancel:    cancel,
-		running:   true,
+		semaphore:  NewSemaphore(concurrency),
+		executor:   executor,
+		middleware: NewChain(),
+		ctx:        ctx,
+		cancel:     cancel,
+		running:    true,
+		inFlight:   make(map[uint64]RunningJob),
+		requeued:   make(map[string]bool),
+		returned:   make(map[string]bool),
 	}
 }
 
@@ -52,11 +126,20 @@ func (cp *ConcurrentProcessor) ProcessJob(job *job.SidekiqJob) error {
 		return fmt.Errorf("failed to acquire semaphore token: context cancelled")
 	}
 
+	// Keep a copy as received so an interrupted job can be requeued unchanged
+	snapshot := *job
+	cp.mu.Lock()
+	cp.nextID++
+	id := cp.nextID
+	cp.inFlight[id] = RunningJob{Job: &snapshot, StartedAt: time.Now()}
+	cp.mu.Unlock()
+
 	// Spawn goroutine to process the job
 	cp.wg.Add(1)
 	go func() {
 		defer cp.wg.Done()
 		defer cp.semaphore.Release()
+		defer cp.finish(id)
 
 		cp.executeJob(job)
 	}()
@@ -64,19 +147,111 @@ func (cp *ConcurrentProcessor) ProcessJob(job *job.SidekiqJob) error {
 	return nil
 }
 
+// execute is the end of the middleware chain. A job requeued while it ran
+// returns ErrRequeued instead of its result.
+func (cp *ConcurrentProcessor) execute(ctx context.Context, jobData *job.SidekiqJob) (result *job.JobResult, err error) {
+	defer func() {
+		if cp.settle(jobData.JID) {
+			result, err = nil, ErrRequeued
+		}
+	}()
+
+	if executor, ok := cp.executor.(ContextJobExecutor); ok {
+		return executor.ExecuteJobContext(ctx, jobData)
+	}
+	return cp.executor.ExecuteJob(jobData)
+}
+
+// settle records that the executor of a job returned, so a later shutdown
+// does not requeue it. It reports whether the job had already been requeued.
+func (cp *ConcurrentProcessor) settle(jid string) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if cp.requeued[jid] {
+		return true
+	}
+	cp.returned[jid] = true
+	return false
+}
+
+// wasRequeued reports whether a running job was pushed back by a timed out shutdown
+func (cp *ConcurrentProcessor) wasRequeued(jid string) bool {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+	return cp.requeued[jid]
+}
+
+// Middleware returns the chain of middleware run around every job execution
+func (cp *ConcurrentProcessor) Middleware() *Chain {
+	return cp.middleware
+}
+
+// finish removes a job from the in-flight set
+func (cp *ConcurrentProcessor) finish(id uint64) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if running, ok := cp.inFlight[id]; ok {
+		delete(cp.requeued, running.Job.JID)
+		delete(cp.returned, running.Job.JID)
+	}
+	delete(cp.inFlight, id)
+}
+
+// RunningJobs returns the jobs currently being executed, oldest first
+func (cp *ConcurrentProcessor) RunningJobs() []RunningJob {
+	cp.mu.RLock()
+	ids := make([]uint64, 0, len(cp.inFlight))
+	for id := range cp.inFlight {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	jobs := make([]RunningJob, len(ids))
+	for i, id := range ids {
+		jobs[i] = cp.inFlight[id]
+	}
+	cp.mu.RUnlock()
+
+	return jobs
+}
+
 // executeJob executes a single job
 func (cp *ConcurrentProcessor) executeJob(job *job.SidekiqJob) {
 	start := time.Now()
 
+	// Expired jobs are skipped before any middleware fetches or decrypts their args
+	if expiration := cp.ExpirationPolicy(); expiration.Expired(job, start) {
+		cp.expire(job, expiration.expiredResult(job))
+		return
+	}
+
 	log.Printf("Starting job execution: JID=%s, Class=%s", job.JID, job.Class)
 
-	result, err := cp.executor.ExecuteJob(job)
+	// Jobs run to completion during graceful shutdown, so they get a context
+	// that is not cancelled with the processor's
+	result, err := cp.middleware.Invoke(context.Background(), job, cp.execute)
+
+	// The requeued copy will run again, so this attempt leaves no trace
+	if errors.Is(err, ErrRequeued) || cp.wasRequeued(job.JID) {
+		log.Printf("Job finished after it was requeued, ignoring its outcome: JID=%s, Class=%s", job.JID, job.Class)
+		return
+	}
 
 	duration := time.Since(start)
+	cp.processed.Add(1)
+
+	cp.mu.RLock()
+	observer := cp.observer
+	cp.mu.RUnlock()
+	if observer != nil {
+		observer.ObserveJob(duration, err)
+	}
 
 	if err != nil {
+		cp.failed.Add(1)
 		log.Printf("Job execution failed: JID=%s, Class=%s, Error=%v, Duration=%v",
 			job.JID, job.Class, err, duration)
+		cp.handleFailure(job, transportFailure(err))
 		return
 	}
 
@@ -84,11 +259,211 @@ func (cp *ConcurrentProcessor) executeJob(job *job.SidekiqJob) {
 		log.Printf("Job execution completed: JID=%s, Class=%s, Duration=%v",
 			job.JID, job.Class, duration)
 	} else {
-		log.Printf("Job execution failed: JID=%s, Class=%s, Error=%s, Duration=%v",
-			job.JID, job.Class, result.ErrorMessage, duration)
+		cp.failed.Add(1)
+		log.Printf("Job execution failed: JID=%s, Class=%s, ErrorClass=%s, Error=%s, Duration=%v",
+			job.JID, job.Class, result.ErrorClass, result.ErrorMessage, duration)
+		cp.handleFailure(job, result)
 	}
 }
 
+// SetObserver registers an observer for job outcomes
+func (cp *ConcurrentProcessor) SetObserver(observer JobObserver) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.observer = observer
+}
+
+// EnableRetries makes the processor retry failed jobs through store according to policy
+func (cp *ConcurrentProcessor) EnableRetries(store redis.RedisClient, policy RetryPolicy) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.store = store
+	cp.retryPolicy = policy
+}
+
+// SetRetryPolicy replaces the retry policy used for subsequent failures
+func (cp *ConcurrentProcessor) SetRetryPolicy(policy RetryPolicy) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.retryPolicy = policy
+}
+
+// RetryPolicy returns the current retry policy
+func (cp *ConcurrentProcessor) RetryPolicy() RetryPolicy {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+	return cp.retryPolicy
+}
+
+// SetExpirationPolicy replaces the expiration policy used for subsequent jobs
+func (cp *ConcurrentProcessor) SetExpirationPolicy(policy ExpirationPolicy) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.expiration = policy
+}
+
+// ExpirationPolicy returns the current expiration policy
+func (cp *ConcurrentProcessor) ExpirationPolicy() ExpirationPolicy {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+	return cp.expiration
+}
+
+// expire counts a job skipped because it expired, keeps it in the expired set
+// if the policy asks to, otherwise runs the drop handlers, and runs the death
+// handlers, so workflows and the like treat it as failed for good. It is
+// neither processed nor failed.
+func (cp *ConcurrentProcessor) expire(job *job.SidekiqJob, result *job.JobResult) {
+	cp.expired.Add(1)
+	log.Printf("Job expired, skipping: JID=%s, Class=%s, Error=%s", job.JID, job.Class, result.ErrorMessage)
+
+	cp.mu.RLock()
+	store, policy, dropHandlers := cp.store, cp.expiration, cp.dropHandlers
+	cp.mu.RUnlock()
+	if store != nil && policy.KeepExpired {
+		if err := store.MoveToExpired(job); err != nil {
+			log.Printf("Failed to record expired job: JID=%s, Class=%s, Error=%v", job.JID, job.Class, err)
+		}
+	} else {
+		for _, handler := range dropHandlers {
+			handler(job)
+		}
+	}
+
+	cp.notifyDeath(job, result)
+}
+
+// RetryJob schedules a failed job for another attempt, or moves it to the
+// dead set once the policy's attempts are exhausted
+func (cp *ConcurrentProcessor) RetryJob(job *job.SidekiqJob, attempt int) error {
+	return cp.retryJob(job, attempt, 0, nil)
+}
+
+// retryJob is RetryJob with an optional delay overriding the policy's backoff
+// and the failure passed to death handlers
+func (cp *ConcurrentProcessor) retryJob(job *job.SidekiqJob, attempt int, delay time.Duration, result *job.JobResult) error {
+	cp.mu.RLock()
+	store, policy := cp.store, cp.retryPolicy
+	cp.mu.RUnlock()
+
+	if store == nil {
+		return fmt.Errorf("retries are not enabled")
+	}
+
+	if attempt >= policy.MaxAttempts {
+		log.Printf("Job retries exhausted, moving to dead set: JID=%s, Class=%s, Attempts=%d",
+			job.JID, job.Class, attempt)
+		return cp.kill(store, job, result)
+	}
+
+	if delay <= 0 {
+		delay = withJitter(policy.Delay(attempt))
+	}
+	log.Printf("Scheduling job retry: JID=%s, Class=%s, Attempt=%d, Delay=%v",
+		job.JID, job.Class, attempt+1, delay)
+	return store.EnqueueRetry(job, delay)
+}
+
+// handleFailure records the error on the job payload, then retries, kills or
+// discards the job as the failure result asks
+func (cp *ConcurrentProcessor) handleFailure(job *job.SidekiqJob, result *job.JobResult) {
+	cp.mu.RLock()
+	store := cp.store
+	cp.mu.RUnlock()
+	if store == nil {
+		return
+	}
+
+	if result.Discard {
+		log.Printf("Job discarded: JID=%s, Class=%s", job.JID, job.Class)
+		cp.notifyDeath(job, result)
+		return
+	}
+
+	recordFailure(job, result)
+
+	var err error
+	switch {
+	case result.Retryable != nil && !*result.Retryable:
+		log.Printf("Job is not retryable, moving to dead set: JID=%s, Class=%s", job.JID, job.Class)
+		err = cp.kill(store, job, result)
+	default:
+		err = cp.retryJob(job, job.Retry, time.Duration(result.RetryIn*float64(time.Second)), result)
+	}
+	if err != nil {
+		log.Printf("Failed to schedule retry: JID=%s, Class=%s, Error=%v", job.JID, job.Class, err)
+	}
+}
+
+// kill moves a job to the dead set and runs the death handlers
+func (cp *ConcurrentProcessor) kill(store redis.RedisClient, job *job.SidekiqJob, result *job.JobResult) error {
+	if err := store.MoveToDLQ(job); err != nil {
+		return err
+	}
+	cp.notifyDeath(job, result)
+	return nil
+}
+
+// OnDeath registers a handler called whenever a job is moved to the dead set
+// or discarded, like Sidekiq's death handlers
+func (cp *ConcurrentProcessor) OnDeath(handler DeathHandler) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.deathHandlers = append(cp.deathHandlers, handler)
+}
+
+// OnDrop registers a handler called whenever an expired job is dropped
+// rather than kept in the expired set
+func (cp *ConcurrentProcessor) OnDrop(handler DropHandler) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.dropHandlers = append(cp.dropHandlers, handler)
+}
+
+func (cp *ConcurrentProcessor) notifyDeath(jobData *job.SidekiqJob, result *job.JobResult) {
+	cp.mu.RLock()
+	handlers := cp.deathHandlers
+	cp.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(jobData, result)
+	}
+}
+
+// transportFailure describes an ExecuteJob error as a failed result
+func transportFailure(err error) *job.JobResult {
+	return &job.JobResult{Status: "failure", ErrorClass: sidecarErrorClass, ErrorMessage: err.Error()}
+}
+
+// recordFailure writes the failure into the payload the way Sidekiq does
+func recordFailure(job *job.SidekiqJob, result *job.JobResult) {
+	job.ErrorClass = result.ErrorClass
+	if job.ErrorClass == "" {
+		job.ErrorClass = defaultErrorClass
+	}
+	job.ErrorMsg = result.ErrorMessage
+
+	job.ErrorBacktrace = result.Backtrace
+	if len(job.ErrorBacktrace) > maxBacktraceLines {
+		job.ErrorBacktrace = job.ErrorBacktrace[:maxBacktraceLines]
+	}
+
+	for _, tag := range result.Tags {
+		if !containsTag(job.Tags, tag) {
+			job.Tags = append(job.Tags, tag)
+		}
+	}
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // Shutdown initiates graceful shutdown of the processor
 func (cp *ConcurrentProcessor) Shutdown(timeout time.Duration) error {
 	cp.mu.Lock()
@@ -118,10 +493,57 @@ func (cp *ConcurrentProcessor) Shutdown(timeout time.Duration) error {
 		return nil
 	case <-time.After(timeout):
 		log.Printf("Graceful shutdown timed out after %v", timeout)
+		cp.requeueInFlight()
 		return fmt.Errorf("shutdown timeout exceeded")
 	}
 }
 
+// requeueInFlight pushes jobs that are still running back onto the head of
+// their queues so another process picks them up, as Sidekiq does on shutdown.
+// Their JIDs are recorded first, so the outcome of an attempt that finishes
+// afterwards is ignored rather than retried or killed as well.
+func (cp *ConcurrentProcessor) requeueInFlight() {
+	running := cp.RunningJobs()
+	if len(running) == 0 {
+		return
+	}
+
+	cp.mu.Lock()
+	store := cp.store
+	var jobs []*job.SidekiqJob
+	if store != nil {
+		for _, r := range running {
+			if !cp.returned[r.Job.JID] {
+				cp.requeued[r.Job.JID] = true
+				jobs = append(jobs, r.Job)
+			}
+		}
+	}
+	cp.mu.Unlock()
+	if store == nil {
+		log.Printf("Abandoning %d unfinished jobs: no store to requeue them to", len(running))
+		return
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	for _, requeued := range jobs {
+		log.Printf("Requeuing unfinished job: JID=%s, Class=%s, Queue=%s", requeued.JID, requeued.Class, requeued.Queue)
+	}
+
+	if err := store.RequeueInterrupted(jobs); err != nil {
+		log.Printf("Failed to requeue %d unfinished jobs: %v", len(jobs), err)
+		cp.mu.Lock()
+		for _, requeued := range jobs {
+			delete(cp.requeued, requeued.JID)
+		}
+		cp.mu.Unlock()
+		return
+	}
+	log.Printf("Requeued %d unfinished jobs", len(jobs))
+}
+
 // ActiveJobs returns the number of currently active jobs
 func (cp *ConcurrentProcessor) ActiveJobs() int {
 	return cp.semaphore.ActiveCount()
@@ -132,6 +554,32 @@ func (cp *ConcurrentProcessor) Capacity() int {
 	return cp.semaphore.Capacity()
 }
 
+// SetConcurrency changes the maximum number of jobs executed at once. Jobs
+// already running above a lowered limit are allowed to finish.
+func (cp *ConcurrentProcessor) SetConcurrency(concurrency int) {
+	cp.semaphore.Resize(concurrency)
+}
+
+// WaitingJobs returns the number of jobs blocked waiting for a free slot
+func (cp *ConcurrentProcessor) WaitingJobs() int {
+	return cp.semaphore.Waiting()
+}
+
+// ProcessedCount returns the total number of jobs executed, including failures
+func (cp *ConcurrentProcessor) ProcessedCount() int64 {
+	return cp.processed.Load()
+}
+
+// FailedCount returns the total number of jobs that failed
+func (cp *ConcurrentProcessor) FailedCount() int64 {
+	return cp.failed.Load()
+}
+
+// ExpiredCount returns the total number of jobs skipped because they expired
+func (cp *ConcurrentProcessor) ExpiredCount() int64 {
+	return cp.expired.Load()
+}
+
 // IsRunning returns whether the processor is currently accepting new jobs
 func (cp *ConcurrentProcessor) IsRunning() bool {
 	cp.mu.RLock()
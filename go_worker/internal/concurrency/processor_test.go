@@ -1,6 +1,7 @@
 package concurrency
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
@@ -73,6 +74,45 @@ func (m *MockJobExecutor) SetShouldFail(shouldFail bool, err error) {
 	m.failureError = err
 }
 
+// mockStore implements redis.RedisClient, recording what the processor persists
+type mockStore struct {
+	mu          sync.Mutex
+	retried     []*job.SidekiqJob
+	dead        []*job.SidekiqJob
+	interrupted []*job.SidekiqJob
+	expired     []*job.SidekiqJob
+}
+
+func (m *mockStore) PollJobs(queues []string) (*job.SidekiqJob, error) { return nil, nil }
+
+func (m *mockStore) EnqueueRetry(j *job.SidekiqJob, delay time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retried = append(m.retried, j)
+	return nil
+}
+
+func (m *mockStore) MoveToDLQ(j *job.SidekiqJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dead = append(m.dead, j)
+	return nil
+}
+
+func (m *mockStore) RequeueInterrupted(jobs []*job.SidekiqJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.interrupted = append(m.interrupted, jobs...)
+	return nil
+}
+
+func (m *mockStore) MoveToExpired(j *job.SidekiqJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expired = append(m.expired, j)
+	return nil
+}
+
 func createTestJob(jid, class string) *job.SidekiqJob {
 	return &job.SidekiqJob{
 		JID:        jid,
@@ -231,6 +271,30 @@ func TestConcurrentProcessor_JobFailure(t *testing.T) {
 	}
 }
 
+func TestConcurrentProcessor_Counters(t *testing.T) {
+	executor := NewMockJobExecutor()
+	processor := NewConcurrentProcessor(2, executor)
+
+	processor.ProcessJob(createTestJob("ok-job", "TestJob"))
+	processor.Shutdown(time.Second)
+
+	failing := NewMockJobExecutor()
+	failing.SetShouldFail(true, errors.New("job execution failed"))
+	failingProcessor := NewConcurrentProcessor(2, failing)
+
+	failingProcessor.ProcessJob(createTestJob("failing-job", "FailingJob"))
+	failingProcessor.Shutdown(time.Second)
+
+	if processor.ProcessedCount() != 1 || processor.FailedCount() != 0 {
+		t.Errorf("Expected 1 processed and 0 failed, got %d and %d",
+			processor.ProcessedCount(), processor.FailedCount())
+	}
+	if failingProcessor.ProcessedCount() != 1 || failingProcessor.FailedCount() != 1 {
+		t.Errorf("Expected 1 processed and 1 failed, got %d and %d",
+			failingProcessor.ProcessedCount(), failingProcessor.FailedCount())
+	}
+}
+
 func TestConcurrentProcessor_Shutdown(t *testing.T) {
 	executor := NewMockJobExecutor()
 	executor.SetExecutionTime(100 * time.Millisecond)
@@ -300,6 +364,105 @@ func TestConcurrentProcessor_ShutdownTimeout(t *testing.T) {
 	}
 }
 
+func TestConcurrentProcessor_ShutdownRequeuesUnfinished(t *testing.T) {
+	executor := NewMockJobExecutor()
+	executor.SetExecutionTime(200 * time.Millisecond)
+
+	store := &mockStore{}
+	processor := NewConcurrentProcessor(2, executor)
+	processor.EnableRetries(store, RetryPolicy{MaxAttempts: 25, BaseDelay: time.Second, MaxDelay: time.Hour})
+
+	processor.ProcessJob(createTestJob("long-1", "LongJob"))
+	processor.ProcessJob(createTestJob("long-2", "LongJob"))
+	time.Sleep(10 * time.Millisecond)
+
+	if err := processor.Shutdown(20 * time.Millisecond); err == nil {
+		t.Fatal("Shutdown should return error when timeout occurs")
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.interrupted) != 2 {
+		t.Fatalf("Expected 2 requeued jobs, got %d", len(store.interrupted))
+	}
+	if store.interrupted[0].JID != "long-1" || store.interrupted[1].JID != "long-2" {
+		t.Errorf("Expected jobs requeued in start order, got %s, %s",
+			store.interrupted[0].JID, store.interrupted[1].JID)
+	}
+}
+
+func TestConcurrentProcessor_IgnoresOutcomeOfRequeuedJob(t *testing.T) {
+	executor := NewMockJobExecutor()
+	executor.SetExecutionTime(100 * time.Millisecond)
+	executor.SetShouldFail(true, errors.New("connection refused"))
+
+	store := &mockStore{}
+	processor := NewConcurrentProcessor(1, executor)
+	processor.EnableRetries(store, RetryPolicy{MaxAttempts: 25, BaseDelay: time.Second, MaxDelay: time.Hour})
+	var mu sync.Mutex
+	var deaths int
+	var middlewareErr error
+	processor.OnDeath(func(*job.SidekiqJob, *job.JobResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		deaths++
+	})
+	processor.Middleware().Add("track", func(ctx context.Context, jobData *job.SidekiqJob, next Next) (*job.JobResult, error) {
+		result, err := next(ctx, jobData)
+		mu.Lock()
+		defer mu.Unlock()
+		middlewareErr = err
+		return result, err
+	})
+
+	processor.ProcessJob(createTestJob("long", "LongJob"))
+	time.Sleep(10 * time.Millisecond)
+	if err := processor.Shutdown(20 * time.Millisecond); err == nil {
+		t.Fatal("Shutdown should return error when timeout occurs")
+	}
+	// Let the abandoned attempt fail
+	time.Sleep(150 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.interrupted) != 1 {
+		t.Fatalf("Expected the job to be requeued, got %d", len(store.interrupted))
+	}
+	if len(store.retried) != 0 || len(store.dead) != 0 || deaths != 0 {
+		t.Errorf("Expected the requeued job's failure to be ignored: retried=%d, dead=%d, deaths=%d",
+			len(store.retried), len(store.dead), deaths)
+	}
+	if !errors.Is(middlewareErr, ErrRequeued) {
+		t.Errorf("Expected middleware to see ErrRequeued, got %v", middlewareErr)
+	}
+	if processor.ProcessedCount() != 0 || processor.FailedCount() != 0 {
+		t.Errorf("Expected no stats for the requeued job, processed=%d failed=%d", processor.ProcessedCount(), processor.FailedCount())
+	}
+}
+
+func TestConcurrentProcessor_RetryJob(t *testing.T) {
+	store := &mockStore{}
+	processor := NewConcurrentProcessor(1, NewMockJobExecutor())
+	defer processor.Shutdown(time.Second)
+
+	if err := processor.RetryJob(createTestJob("jid", "TestJob"), 0); err == nil {
+		t.Error("RetryJob should fail before retries are enabled")
+	}
+
+	processor.EnableRetries(store, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Second, MaxDelay: time.Hour})
+	processor.RetryJob(createTestJob("retry", "TestJob"), 1)
+	processor.RetryJob(createTestJob("dead", "TestJob"), 2)
+
+	if len(store.retried) != 1 || store.retried[0].JID != "retry" {
+		t.Errorf("Expected one retried job, got %v", store.retried)
+	}
+	if len(store.dead) != 1 || store.dead[0].JID != "dead" {
+		t.Errorf("Expected one dead job, got %v", store.dead)
+	}
+}
+
 func TestConcurrentProcessor_ProcessJobAfterShutdown(t *testing.T) {
 	executor := NewMockJobExecutor()
 	processor := NewConcurrentProcessor(2, executor)
@@ -324,3 +487,164 @@ func TestConcurrentProcessor_ProcessJobAfterShutdown(t *testing.T) {
 		t.Errorf("Expected 0 executed jobs, got %d", len(executedJobs))
 	}
 }
+
+// resultExecutor returns a fixed result for every job
+type resultExecutor struct {
+	result *job.JobResult
+}
+
+func (e resultExecutor) ExecuteJob(*job.SidekiqJob) (*job.JobResult, error) {
+	return e.result, nil
+}
+
+func TestConcurrentProcessor_FailureResult(t *testing.T) {
+	notRetryable := false
+	backtrace := make([]string, 60)
+	for i := range backtrace {
+		backtrace[i] = fmt.Sprintf("app/jobs/charge_job.rb:%d", i)
+	}
+
+	tests := []struct {
+		name      string
+		result    *job.JobResult
+		wantRetry bool
+		wantDead  bool
+		wantDeath bool
+	}{
+		{"retryable failure", &job.JobResult{Status: "failure", ErrorClass: "Timeout::Error", ErrorMessage: "slow", Backtrace: backtrace, Tags: []string{"billing"}}, true, false, false},
+		{"not retryable", &job.JobResult{Status: "failure", ErrorClass: "ArgumentError", Retryable: &notRetryable}, false, true, true},
+		{"discarded", &job.JobResult{Status: "failure", ErrorClass: "ActiveRecord::RecordNotFound", Discard: true}, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockStore{}
+			processor := NewConcurrentProcessor(1, resultExecutor{result: tt.result})
+			processor.EnableRetries(store, RetryPolicy{MaxAttempts: 25, BaseDelay: time.Second, MaxDelay: time.Hour})
+			var deaths []*job.JobResult
+			processor.OnDeath(func(dead *job.SidekiqJob, result *job.JobResult) {
+				deaths = append(deaths, result)
+			})
+
+			testJob := createTestJob("jid", "ChargeJob")
+			testJob.Tags = []string{"billing"}
+			processor.ProcessJob(testJob)
+			processor.Shutdown(time.Second)
+
+			if (len(deaths) == 1) != tt.wantDeath {
+				t.Errorf("death handler called %d times, want death %v", len(deaths), tt.wantDeath)
+			}
+			if tt.wantDeath && deaths[0] != tt.result {
+				t.Errorf("Expected death handler to receive the failure result, got %+v", deaths[0])
+			}
+
+			if (len(store.retried) == 1) != tt.wantRetry {
+				t.Errorf("retried = %d jobs, want retry %v", len(store.retried), tt.wantRetry)
+			}
+			if (len(store.dead) == 1) != tt.wantDead {
+				t.Errorf("dead = %d jobs, want dead %v", len(store.dead), tt.wantDead)
+			}
+
+			if tt.wantRetry {
+				retried := store.retried[0]
+				if retried.ErrorClass != "Timeout::Error" || retried.ErrorMsg != "slow" {
+					t.Errorf("Expected error recorded on payload, got %s: %s", retried.ErrorClass, retried.ErrorMsg)
+				}
+				if len(retried.ErrorBacktrace) != maxBacktraceLines {
+					t.Errorf("Expected backtrace capped at %d lines, got %d", maxBacktraceLines, len(retried.ErrorBacktrace))
+				}
+				if len(retried.Tags) != 1 {
+					t.Errorf("Expected tags to be merged without duplicates, got %v", retried.Tags)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentProcessor_ExpiredJob(t *testing.T) {
+	executor := NewMockJobExecutor()
+	store := &mockStore{}
+	processor := NewConcurrentProcessor(1, executor)
+	processor.EnableRetries(store, RetryPolicy{MaxAttempts: 25, BaseDelay: time.Second, MaxDelay: time.Hour})
+	processor.SetExpirationPolicy(ExpirationPolicy{TTLs: map[string]time.Duration{"NotifyJob": time.Hour}, KeepExpired: true})
+	var deaths []*job.JobResult
+	processor.OnDeath(func(dead *job.SidekiqJob, result *job.JobResult) {
+		deaths = append(deaths, result)
+	})
+	var dropped []string
+	processor.OnDrop(func(jobData *job.SidekiqJob) {
+		dropped = append(dropped, jobData.JID)
+	})
+	var invoked []string
+	processor.Middleware().Add("track", func(ctx context.Context, jobData *job.SidekiqJob, next Next) (*job.JobResult, error) {
+		invoked = append(invoked, jobData.JID)
+		return next(ctx, jobData)
+	})
+
+	stale := createTestJob("stale", "NotifyJob")
+	stale.CreatedAt = float64(time.Now().Add(-2 * time.Hour).Unix())
+	processor.ProcessJob(stale)
+	processor.ProcessJob(createTestJob("fresh", "NotifyJob"))
+	processor.Shutdown(time.Second)
+
+	if executor.GetCallCount() != 1 {
+		t.Errorf("Expected only the fresh job to run, got %d executions", executor.GetCallCount())
+	}
+	if processor.ExpiredCount() != 1 || processor.ProcessedCount() != 1 || processor.FailedCount() != 0 {
+		t.Errorf("Unexpected counters: expired=%d, processed=%d, failed=%d",
+			processor.ExpiredCount(), processor.ProcessedCount(), processor.FailedCount())
+	}
+	if len(store.expired) != 1 || store.expired[0].JID != "stale" {
+		t.Errorf("Expected the stale job in the expired set, got %v", store.expired)
+	}
+	if len(store.retried) != 0 || len(store.dead) != 0 {
+		t.Errorf("Expired job must not be retried or killed: retried=%d, dead=%d", len(store.retried), len(store.dead))
+	}
+	if len(deaths) != 1 || deaths[0].Status != expiredStatus {
+		t.Errorf("Expected the death handler to see the expired result, got %v", deaths)
+	}
+	if len(invoked) != 1 || invoked[0] != "fresh" {
+		t.Errorf("Expected middleware to run only for the fresh job, got %v", invoked)
+	}
+	if len(dropped) != 0 {
+		t.Errorf("Expected a kept job not to be dropped, got %v", dropped)
+	}
+}
+
+func TestConcurrentProcessor_DropsExpiredJob(t *testing.T) {
+	store := &mockStore{}
+	processor := NewConcurrentProcessor(1, NewMockJobExecutor())
+	processor.EnableRetries(store, RetryPolicy{MaxAttempts: 25, BaseDelay: time.Second, MaxDelay: time.Hour})
+	processor.SetExpirationPolicy(ExpirationPolicy{TTLs: map[string]time.Duration{"NotifyJob": time.Hour}})
+	var dropped []string
+	processor.OnDrop(func(jobData *job.SidekiqJob) {
+		dropped = append(dropped, jobData.JID)
+	})
+
+	stale := createTestJob("stale", "NotifyJob")
+	stale.CreatedAt = float64(time.Now().Add(-2 * time.Hour).Unix())
+	processor.ProcessJob(stale)
+	processor.Shutdown(time.Second)
+
+	if len(store.expired) != 0 {
+		t.Errorf("Expected the job not to be kept, got %v", store.expired)
+	}
+	if len(dropped) != 1 || dropped[0] != "stale" {
+		t.Errorf("Expected the drop handler to see the stale job, got %v", dropped)
+	}
+}
+
+func TestConcurrentProcessor_TransportFailure(t *testing.T) {
+	store := &mockStore{}
+	executor := NewMockJobExecutor()
+	executor.SetShouldFail(true, errors.New("connection refused"))
+	processor := NewConcurrentProcessor(1, executor)
+	processor.EnableRetries(store, RetryPolicy{MaxAttempts: 25, BaseDelay: time.Second, MaxDelay: time.Hour})
+
+	processor.ProcessJob(createTestJob("jid", "TestJob"))
+	processor.Shutdown(time.Second)
+
+	if len(store.retried) != 1 || store.retried[0].ErrorClass != sidecarErrorClass {
+		t.Errorf("Expected transport failure to be retried as %s, got %+v", sidecarErrorClass, store.retried)
+	}
+}
@@ -0,0 +1,42 @@
+package concurrency
+
+import (
+	"math/rand"
+	"time"
+
+	"gokiq/internal/config"
+)
+
+// RetryPolicy controls how failed jobs are retried
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// NewRetryPolicy creates a retry policy from configuration
+func NewRetryPolicy(cfg config.RetryConfig) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: cfg.MaxAttempts,
+		BaseDelay:   cfg.BaseDelay,
+		MaxDelay:    cfg.MaxDelay,
+	}
+}
+
+// Delay returns the exponential backoff before the given retry attempt,
+// starting at BaseDelay for attempt 0 and capped at MaxDelay
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// withJitter adds up to 25% random jitter to prevent thundering herd
+func withJitter(delay time.Duration) time.Duration {
+	return delay + time.Duration(rand.Float64()*0.25*float64(delay))
+}
@@ -0,0 +1,37 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 25, BaseDelay: 15 * time.Second, MaxDelay: 2 * time.Minute}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 15 * time.Second},
+		{1, 30 * time.Second},
+		{2, 60 * time.Second},
+		{3, 2 * time.Minute},
+		{20, 2 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Delay(tt.attempt); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	base := 10 * time.Second
+	for i := 0; i < 10; i++ {
+		got := withJitter(base)
+		if got < base || got > time.Duration(float64(base)*1.25) {
+			t.Errorf("withJitter() = %v, want between %v and %v", got, base, time.Duration(float64(base)*1.25))
+		}
+	}
+}
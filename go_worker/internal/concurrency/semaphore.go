@@ -6,12 +6,13 @@ import (
 	"time"
 )
 
-// Semaphore provides a semaphore-based concurrency limiter
+// Semaphore provides a semaphore-based concurrency limiter whose capacity can
+// be changed at runtime. Waiters are served in FIFO order.
 type Semaphore struct {
-	ch       chan struct{}
 	capacity int
 	active   int
-	mu       sync.RWMutex
+	waiters  []chan struct{}
+	mu       sync.Mutex
 	wg       sync.WaitGroup
 }
 
@@ -21,7 +22,6 @@ func NewSemaphore(capacity int) *Semaphore {
 		capacity = 1
 	}
 	return &Semaphore{
-		ch:       make(chan struct{}, capacity),
 		capacity: capacity,
 	}
 }
@@ -29,58 +29,86 @@ func NewSemaphore(capacity int) *Semaphore {
 // Acquire attempts to acquire a semaphore token
 // Returns true if acquired, false if context is cancelled
 func (s *Semaphore) Acquire(ctx context.Context) bool {
-	select {
-	case s.ch <- struct{}{}:
-		s.mu.Lock()
-		s.active++
-		s.wg.Add(1)
+	s.mu.Lock()
+	if s.available() {
+		s.grantLocked()
 		s.mu.Unlock()
 		return true
+	}
+
+	ready := make(chan struct{})
+	s.waiters = append(s.waiters, ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return true
 	case <-ctx.Done():
-		return false
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.removeWaiterLocked(ready) {
+		// The token was granted while the context was being cancelled
+		s.releaseLocked()
+	}
+	return false
 }
 
 // TryAcquire attempts to acquire a semaphore token without blocking
 // Returns true if acquired immediately, false otherwise
 func (s *Semaphore) TryAcquire() bool {
-	select {
-	case s.ch <- struct{}{}:
-		s.mu.Lock()
-		s.active++
-		s.wg.Add(1)
-		s.mu.Unlock()
-		return true
-	default:
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.available() {
 		return false
 	}
+	s.grantLocked()
+	return true
 }
 
 // Release releases a semaphore token
 func (s *Semaphore) Release() {
-	select {
-	case <-s.ch:
-		s.mu.Lock()
-		s.active--
-		s.mu.Unlock()
-		s.wg.Done()
-	default:
-		// Should not happen in normal operation
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+// Resize changes the capacity of the semaphore. Growing wakes waiters
+// immediately; shrinking never revokes held tokens, so new acquisitions block
+// until enough tokens have been released to fall below the new capacity.
+func (s *Semaphore) Resize(capacity int) {
+	if capacity <= 0 {
+		capacity = 1
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.capacity = capacity
+	s.notifyLocked()
 }
 
 // ActiveCount returns the current number of active tokens
 func (s *Semaphore) ActiveCount() int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.active
 }
 
 // Capacity returns the maximum capacity of the semaphore
 func (s *Semaphore) Capacity() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.capacity
 }
 
+// Waiting returns the number of callers blocked in Acquire
+func (s *Semaphore) Waiting() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.waiters)
+}
+
 // Wait waits for all active jobs to complete
 func (s *Semaphore) Wait() {
 	s.wg.Wait()
@@ -102,3 +130,43 @@ func (s *Semaphore) WaitWithTimeout(timeout time.Duration) bool {
 		return false
 	}
 }
+
+// available reports whether a new caller may take a token without jumping the queue
+func (s *Semaphore) available() bool {
+	return s.active < s.capacity && len(s.waiters) == 0
+}
+
+func (s *Semaphore) grantLocked() {
+	s.active++
+	s.wg.Add(1)
+}
+
+func (s *Semaphore) releaseLocked() {
+	if s.active == 0 {
+		// Should not happen in normal operation
+		return
+	}
+	s.active--
+	s.wg.Done()
+	s.notifyLocked()
+}
+
+// notifyLocked hands free tokens to waiters in arrival order
+func (s *Semaphore) notifyLocked() {
+	for len(s.waiters) > 0 && s.active < s.capacity {
+		ready := s.waiters[0]
+		s.waiters = s.waiters[1:]
+		s.grantLocked()
+		close(ready)
+	}
+}
+
+func (s *Semaphore) removeWaiterLocked(ready chan struct{}) bool {
+	for i, waiter := range s.waiters {
+		if waiter == ready {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
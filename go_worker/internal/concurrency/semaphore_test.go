@@ -259,3 +259,110 @@ func TestSemaphore_HighLoad(t *testing.T) {
 
 	t.Logf("Processed %d jobs in %v with capacity %d", totalJobs, duration, capacity)
 }
+
+func TestSemaphore_ResizeGrow(t *testing.T) {
+	sem := NewSemaphore(1)
+	ctx := context.Background()
+
+	if !sem.Acquire(ctx) {
+		t.Fatal("First acquire should succeed")
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		if sem.Acquire(ctx) {
+			close(acquired)
+		}
+	}()
+
+	waitFor(t, func() bool { return sem.Waiting() == 1 })
+
+	sem.Resize(2)
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Waiter should acquire after growing the semaphore")
+	}
+
+	if sem.Capacity() != 2 || sem.ActiveCount() != 2 || sem.Waiting() != 0 {
+		t.Errorf("Unexpected state: capacity=%d active=%d waiting=%d", sem.Capacity(), sem.ActiveCount(), sem.Waiting())
+	}
+}
+
+func TestSemaphore_ResizeShrink(t *testing.T) {
+	sem := NewSemaphore(3)
+	for i := 0; i < 3; i++ {
+		if !sem.TryAcquire() {
+			t.Fatalf("Acquire %d should succeed", i)
+		}
+	}
+
+	// Held tokens survive the shrink
+	sem.Resize(1)
+	if sem.ActiveCount() != 3 {
+		t.Errorf("Active count after shrink = %d, want 3", sem.ActiveCount())
+	}
+
+	sem.Release()
+	if sem.TryAcquire() {
+		t.Error("TryAcquire should fail while active count is above the new capacity")
+	}
+
+	sem.Release()
+	if sem.TryAcquire() {
+		t.Error("TryAcquire should fail while active count equals the new capacity")
+	}
+
+	sem.Release()
+	if !sem.TryAcquire() {
+		t.Error("TryAcquire should succeed once active count is below the new capacity")
+	}
+	sem.Release()
+
+	sem.Resize(0)
+	if sem.Capacity() != 1 {
+		t.Errorf("Resize(0) capacity = %d, want 1", sem.Capacity())
+	}
+}
+
+func TestSemaphore_CancelledWaiter(t *testing.T) {
+	sem := NewSemaphore(1)
+	if !sem.TryAcquire() {
+		t.Fatal("First acquire should succeed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan bool)
+	go func() {
+		result <- sem.Acquire(ctx)
+	}()
+
+	waitFor(t, func() bool { return sem.Waiting() == 1 })
+	cancel()
+
+	if <-result {
+		t.Error("Acquire should return false after cancellation")
+	}
+	if sem.Waiting() != 0 {
+		t.Errorf("Waiting after cancellation = %d, want 0", sem.Waiting())
+	}
+
+	// The cancelled waiter must not hold a token
+	sem.Release()
+	if sem.ActiveCount() != 0 {
+		t.Errorf("Final active count = %d, want 0", sem.ActiveCount())
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -8,26 +8,236 @@ type Config struct {
 	Sidecar SidecarConfig `yaml:"sidecar"`
 	Worker  WorkerConfig  `yaml:"worker"`
 	Retry   RetryConfig   `yaml:"retry"`
+	Admin   AdminConfig   `yaml:"admin"`
+
+	// Executors are additional named sidecars. Each inherits every field it
+	// does not set from the sidecar section.
+	Executors map[string]SidecarConfig `yaml:"executors"`
+
+	// Routes send matching jobs to an executor; the first match wins and
+	// unmatched jobs go to the sidecar section
+	Routes []RouteConfig `yaml:"routes"`
+
+	// Cron lists periodic jobs enqueued by the elected leader process
+	Cron CronConfig `yaml:"cron"`
+
+	// Blob stores large job args outside Redis
+	Blob BlobConfig `yaml:"blob"`
+
+	// Encryption encrypts job args in Redis
+	Encryption EncryptionConfig `yaml:"encryption"`
+
+	// Expiration skips jobs that are too old to be worth running
+	Expiration ExpirationConfig `yaml:"expiration"`
+
+	// source records how the config was loaded so it can be reloaded
+	source *source
 }
 
-// RedisConfig contains Redis connection settings
+// RedisConfig contains Redis connection settings. URL addresses a single
+// node; setting Sentinel.MasterName or Cluster.Addrs connects through Redis
+// Sentinel or Redis Cluster instead.
 type RedisConfig struct {
-	URL      string `yaml:"url"`
-	Password string `yaml:"password"`
-	DB       int    `yaml:"db"`
+	URL string `yaml:"url"`
+	DB  int    `yaml:"db"`
+
+	// Namespace prefixes every key with "<namespace>:", like redis-namespace,
+	// so several applications can share one Redis
+	Namespace string `yaml:"namespace"`
+
+	// Username selects a Redis 6 ACL user. The password is read from
+	// PasswordFile when set, so secrets can be mounted rather than inlined.
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"`
+
+	TLS      TLSConfig       `yaml:"tls"`
+	Sentinel SentinelConfig  `yaml:"sentinel"`
+	Cluster  ClusterConfig   `yaml:"cluster"`
+	Pool     RedisPoolConfig `yaml:"pool"`
+}
+
+// RedisPoolConfig contains Redis connection pool settings. Size 0 derives the
+// pool from worker.concurrency (see DerivePools).
+type RedisPoolConfig struct {
+	Size         int           `yaml:"size"`
+	MinIdle      int           `yaml:"min_idle"`
+	MaxRetries   int           `yaml:"max_retries"`
+	DialTimeout  time.Duration `yaml:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	PoolTimeout  time.Duration `yaml:"pool_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+}
+
+// TLSConfig enables TLS for Redis connections (implied by a rediss:// URL).
+// CAFile replaces the system roots; CertFile and KeyFile present a client
+// certificate for mutual TLS.
+type TLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// SentinelConfig names a Sentinel-managed master and the sentinels to ask for
+// it. Username and Password authenticate against the sentinels themselves.
+type SentinelConfig struct {
+	MasterName string   `yaml:"master_name"`
+	Addrs      []string `yaml:"addrs"`
+	Username   string   `yaml:"username"`
+	Password   string   `yaml:"password"`
+}
+
+// ClusterConfig lists Redis Cluster seed nodes. Every key is prefixed with
+// {HashTag}: so that multi-key operations stay within one hash slot.
+type ClusterConfig struct {
+	Addrs   []string `yaml:"addrs"`
+	HashTag string   `yaml:"hash_tag"`
 }
 
 // SidecarConfig contains Rails sidecar connection settings
 type SidecarConfig struct {
-	URL      string        `yaml:"url"`
-	Timeout  time.Duration `yaml:"timeout"`
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout"`
+	Breaker BreakerConfig `yaml:"breaker"`
+
+	// Retries is how many times a request is re-sent after a network error
+	// or 5xx response; ClassRetries overrides it per job class (0 disables)
+	Retries      int            `yaml:"retries"`
+	ClassRetries map[string]int `yaml:"class_retries"`
+
+	Pool SidecarPoolConfig `yaml:"pool"`
+}
+
+// SidecarPoolConfig contains sidecar HTTP connection pool settings. Each job
+// holds a connection while it runs, so MaxConns and MaxIdleConns of 0 derive
+// from worker.concurrency (see DerivePools).
+type SidecarPoolConfig struct {
+	MaxConns        int           `yaml:"max_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout"`
+	DialTimeout     time.Duration `yaml:"dial_timeout"`
+}
+
+// RouteConfig sends jobs to a named executor. Classes are shell patterns
+// (e.g. "Billing::*"); when both Classes and Queues are set a job must match both.
+type RouteConfig struct {
+	Executor string   `yaml:"executor"`
+	Classes  []string `yaml:"classes"`
+	Queues   []string `yaml:"queues"`
+}
+
+// CronConfig contains periodic job settings. One worker process holds the
+// leader lock for LeaderTTL and renews it while it enqueues ticks. Ticks older
+// than Grace are missed ticks, handled by each job's catch-up policy.
+type CronConfig struct {
+	LeaderTTL  time.Duration   `yaml:"leader_ttl"`
+	Grace      time.Duration   `yaml:"grace"`
+	CatchUp    string          `yaml:"catch_up"`
+	MaxCatchUp int             `yaml:"max_catch_up"`
+	Jobs       []CronJobConfig `yaml:"jobs"`
+}
+
+// CronJobConfig is a periodic job. Schedule is a five-field cron expression or
+// an @-shorthand evaluated in Timezone (UTC when empty); CatchUp overrides the
+// cron section's policy ("skip", "last" or "all").
+type CronJobConfig struct {
+	Name     string        `yaml:"name"`
+	Class    string        `yaml:"class"`
+	Queue    string        `yaml:"queue"`
+	Args     []interface{} `yaml:"args"`
+	Schedule string        `yaml:"schedule"`
+	Timezone string        `yaml:"timezone"`
+	CatchUp  string        `yaml:"catch_up"`
+}
+
+// BlobConfig offloads job args whose JSON is larger than Threshold bytes to a
+// blob store, leaving only a reference in Redis. Backend is "local" or "s3";
+// empty disables offloading and resolving. A Threshold of 0 resolves
+// references written by other producers without offloading anything.
+type BlobConfig struct {
+	Backend   string          `yaml:"backend"`
+	Threshold int             `yaml:"threshold"`
+	Local     LocalBlobConfig `yaml:"local"`
+	S3        S3BlobConfig    `yaml:"s3"`
+}
+
+// LocalBlobConfig stores blobs as files under Dir, which every worker and
+// producer must share
+type LocalBlobConfig struct {
+	Dir string `yaml:"dir"`
+}
+
+// S3BlobConfig stores blobs in an S3-compatible bucket, addressed path-style
+// as <endpoint>/<bucket>/<prefix><key>
+type S3BlobConfig struct {
+	Endpoint        string        `yaml:"endpoint"`
+	Region          string        `yaml:"region"`
+	Bucket          string        `yaml:"bucket"`
+	Prefix          string        `yaml:"prefix"`
+	AccessKeyID     string        `yaml:"access_key_id"`
+	SecretAccessKey string        `yaml:"secret_access_key"`
+	SessionToken    string        `yaml:"session_token"`
+	Timeout         time.Duration `yaml:"timeout"`
+}
+
+// EncryptionConfig encrypts the args of pushed jobs with AES-GCM. Keys maps
+// key IDs to base64-encoded 16, 24 or 32 byte keys. Jobs are encrypted with
+// Current and decrypted with whichever key they name, so a key is rotated by
+// adding a new one, making it Current and removing the old one once no job
+// encrypted with it remains.
+type EncryptionConfig struct {
+	Enabled bool              `yaml:"enabled"`
+	Current string            `yaml:"current"`
+	Keys    map[string]string `yaml:"keys"`
+}
+
+// ExpirationConfig skips jobs that expired before they could run. TTLs maps
+// job classes to how long after creation their jobs expire; a payload's
+// expires_at or expires_in takes precedence. KeepExpired records skipped jobs
+// in the expired set.
+type ExpirationConfig struct {
+	TTLs        map[string]time.Duration `yaml:"ttls"`
+	KeepExpired bool                     `yaml:"keep_expired"`
+}
+
+// BreakerConfig contains sidecar circuit breaker settings. The breaker opens
+// when at least FailureThreshold requests failed within Window and they make
+// up at least FailureRate of all requests in that window.
+type BreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"`
+	FailureRate      float64       `yaml:"failure_rate"`
+	Window           time.Duration `yaml:"window"`
+	ResetTimeout     time.Duration `yaml:"reset_timeout"`
+	HalfOpenProbes   int           `yaml:"half_open_probes"`
 }
 
 // WorkerConfig contains worker behavior settings
 type WorkerConfig struct {
-	Concurrency  int           `yaml:"concurrency"`
-	Queues       []string      `yaml:"queues"`
-	PollInterval time.Duration `yaml:"poll_interval"`
+	Concurrency  int            `yaml:"concurrency"`
+	Queues       []string       `yaml:"queues"`
+	Weights      map[string]int `yaml:"weights"`
+	PollInterval time.Duration  `yaml:"poll_interval"`
+	Adaptive     AdaptiveConfig `yaml:"adaptive"`
+
+	// Middleware lists the server middleware to run, in order. When empty every
+	// registered middleware runs in registration order.
+	Middleware []string `yaml:"middleware"`
+}
+
+// AdaptiveConfig contains settings for adjusting concurrency from observed
+// sidecar latency and error rates
+type AdaptiveConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	Min              int           `yaml:"min"`
+	Max              int           `yaml:"max"`
+	Interval         time.Duration `yaml:"interval"`
+	LatencyTolerance float64       `yaml:"latency_tolerance"`
+	MaxErrorRate     float64       `yaml:"max_error_rate"`
+	Backoff          float64       `yaml:"backoff"`
 }
 
 // RetryConfig contains retry policy settings
@@ -36,3 +246,10 @@ type RetryConfig struct {
 	BaseDelay   time.Duration `yaml:"base_delay"`
 	MaxDelay    time.Duration `yaml:"max_delay"`
 }
+
+// AdminConfig contains administrative HTTP API settings
+type AdminConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Addr    string `yaml:"addr"`
+	Token   string `yaml:"token"`
+}
@@ -0,0 +1,108 @@
+package config
+
+import "time"
+
+// Default returns the configuration used for any field not set in YAML or the environment
+func Default() *Config {
+	return &Config{
+		Redis: RedisConfig{
+			URL: "localhost:6379",
+			Cluster: ClusterConfig{
+				HashTag: "gokiq",
+			},
+			Pool: RedisPoolConfig{
+				MinIdle:      5,
+				MaxRetries:   3,
+				DialTimeout:  5 * time.Second,
+				ReadTimeout:  3 * time.Second,
+				WriteTimeout: 3 * time.Second,
+				PoolTimeout:  4 * time.Second,
+				IdleTimeout:  5 * time.Minute,
+			},
+		},
+		Sidecar: SidecarConfig{
+			URL:     "http://localhost:9292",
+			Timeout: 30 * time.Second,
+			Breaker: BreakerConfig{
+				FailureThreshold: 10,
+				FailureRate:      0.5,
+				Window:           time.Minute,
+				ResetTimeout:     30 * time.Second,
+				HalfOpenProbes:   3,
+			},
+			Retries: 3,
+			Pool: SidecarPoolConfig{
+				IdleConnTimeout: 90 * time.Second,
+				DialTimeout:     5 * time.Second,
+			},
+		},
+		Worker: WorkerConfig{
+			Concurrency:  10,
+			Queues:       []string{"default"},
+			PollInterval: 100 * time.Millisecond,
+			Adaptive: AdaptiveConfig{
+				Min:              1,
+				Max:              100,
+				Interval:         5 * time.Second,
+				LatencyTolerance: 2,
+				MaxErrorRate:     0.1,
+				Backoff:          0.9,
+			},
+		},
+		Retry: RetryConfig{
+			MaxAttempts: 25,
+			BaseDelay:   15 * time.Second,
+			MaxDelay:    24 * time.Hour,
+		},
+		Cron: CronConfig{
+			LeaderTTL:  30 * time.Second,
+			Grace:      time.Minute,
+			CatchUp:    "skip",
+			MaxCatchUp: 10,
+		},
+		Blob: BlobConfig{
+			Threshold: 100 * 1024,
+			S3: S3BlobConfig{
+				Region:  "us-east-1",
+				Timeout: 30 * time.Second,
+			},
+		},
+		Admin: AdminConfig{
+			Addr: ":7433",
+		},
+	}
+}
+
+// DerivePools fills in pool sizes left at 0 from the most jobs the worker can
+// run at once: worker.concurrency, or worker.adaptive.max when adaptive
+// concurrency is enabled. Every running job holds a sidecar connection, so
+// sidecar pools get one per job; jobs only touch Redis briefly around
+// execution, so its pool gets one per four jobs plus room for polling,
+// heartbeats and the scheduler. Load calls it after applying every source.
+func (c *Config) DerivePools() {
+	jobs := c.Worker.Concurrency
+	if c.Worker.Adaptive.Enabled && c.Worker.Adaptive.Max > jobs {
+		jobs = c.Worker.Adaptive.Max
+	}
+
+	if c.Redis.Pool.Size == 0 {
+		c.Redis.Pool.Size = 10 + jobs/4
+	}
+	if c.Redis.Pool.MinIdle > c.Redis.Pool.Size {
+		c.Redis.Pool.MinIdle = c.Redis.Pool.Size
+	}
+
+	derive := func(pool *SidecarPoolConfig) {
+		if pool.MaxConns == 0 {
+			pool.MaxConns = jobs
+		}
+		if pool.MaxIdleConns == 0 {
+			pool.MaxIdleConns = pool.MaxConns
+		}
+	}
+	derive(&c.Sidecar.Pool)
+	for name, executor := range c.Executors {
+		derive(&executor.Pool)
+		c.Executors[name] = executor
+	}
+}
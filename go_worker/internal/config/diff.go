@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff returns the dotted yaml paths of every field that differs between a
+// and b, e.g. "worker.concurrency", "retry.max_attempts" or, inside maps of
+// sections, "executors.billing.timeout"
+func Diff(a, b *Config) []string {
+	var changed []string
+	diffStruct(reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), "", &changed)
+	return changed
+}
+
+func diffStruct(a, b reflect.Value, prefix string, changed *[]string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		path := tag
+		if prefix != "" {
+			path = prefix + "." + tag
+		}
+
+		if a.Field(i).Kind() == reflect.Struct {
+			diffStruct(a.Field(i), b.Field(i), path, changed)
+			continue
+		}
+
+		if a.Field(i).Kind() == reflect.Map && a.Field(i).Type().Elem().Kind() == reflect.Struct {
+			diffStructMap(a.Field(i), b.Field(i), path, changed)
+			continue
+		}
+
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			*changed = append(*changed, path)
+		}
+	}
+}
+
+// diffStructMap compares maps of sections key by key. An added or removed key
+// changes the path of the whole entry.
+func diffStructMap(a, b reflect.Value, prefix string, changed *[]string) {
+	keys := map[string]reflect.Value{}
+	for _, key := range append(a.MapKeys(), b.MapKeys()...) {
+		keys[fmt.Sprint(key.Interface())] = key
+	}
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := prefix + "." + name
+		av, bv := a.MapIndex(keys[name]), b.MapIndex(keys[name])
+		if !av.IsValid() || !bv.IsValid() {
+			*changed = append(*changed, path)
+			continue
+		}
+		diffStruct(av, bv, path, changed)
+	}
+}
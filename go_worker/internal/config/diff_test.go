@@ -0,0 +1,44 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	a := Default()
+	b := Default()
+
+	if changed := Diff(a, b); len(changed) != 0 {
+		t.Errorf("Expected no changes, got %v", changed)
+	}
+
+	b.Worker.Concurrency = 99
+	b.Worker.Queues = []string{"critical", "default"}
+	b.Worker.Weights = map[string]int{"critical": 3}
+	b.Retry.MaxDelay = time.Hour
+	b.Redis.URL = "redis://elsewhere:6379"
+
+	want := []string{"redis.url", "worker.concurrency", "worker.queues", "worker.weights", "retry.max_delay"}
+	if changed := Diff(a, b); !reflect.DeepEqual(changed, want) {
+		t.Errorf("Diff() = %v, want %v", changed, want)
+	}
+}
+
+func TestDiff_Executors(t *testing.T) {
+	a := Default()
+	a.Executors = map[string]SidecarConfig{"billing": a.Sidecar, "reports": a.Sidecar}
+	b := Default()
+	b.Executors = map[string]SidecarConfig{"billing": b.Sidecar, "search": b.Sidecar}
+
+	billing := b.Executors["billing"]
+	billing.Timeout = time.Minute
+	billing.URL = "http://billing:9292"
+	b.Executors["billing"] = billing
+
+	want := []string{"executors.billing.url", "executors.billing.timeout", "executors.reports", "executors.search"}
+	if changed := Diff(a, b); !reflect.DeepEqual(changed, want) {
+		t.Errorf("Diff() = %v, want %v", changed, want)
+	}
+}
@@ -0,0 +1,362 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultPath is the configuration file used when none is specified
+const DefaultPath = "config/config.yaml"
+
+// EnvPrefix prefixes the environment variable generated for every config field,
+// e.g. GOKIQ_WORKER_POLL_INTERVAL overrides worker.poll_interval
+const EnvPrefix = "GOKIQ"
+
+// legacyEnv maps the original environment variable names to their config fields
+var legacyEnv = map[string]string{
+	"REDIS_URL":          "GOKIQ_REDIS_URL",
+	"SIDECAR_URL":        "GOKIQ_SIDECAR_URL",
+	"WORKER_CONCURRENCY": "GOKIQ_WORKER_CONCURRENCY",
+	"ADMIN_TOKEN":        "GOKIQ_ADMIN_TOKEN",
+}
+
+// envRefPattern matches ${VAR} and ${VAR:-default} references inside YAML strings
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// source is the file and overrides a config was loaded from
+type source struct {
+	path      string
+	overrides []func(*Config)
+}
+
+// Load builds the configuration from defaults, the YAML file at path and
+// environment variables, in increasing order of precedence. Overrides are
+// applied last, then the result is validated.
+func Load(path string, overrides ...func(*Config)) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	expanded, err := expandEnvRefs(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(expanded, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if err := applyEnv(cfg, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := inheritExecutors(expanded, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for _, override := range overrides {
+		override(cfg)
+	}
+	cfg.DerivePools()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfg.source = &source{path: path, overrides: overrides}
+	return cfg, nil
+}
+
+// Reload re-reads the configuration from the same file, environment and
+// overrides it was originally loaded with
+func (c *Config) Reload() (*Config, error) {
+	if c.source == nil {
+		return nil, fmt.Errorf("configuration was not loaded from a file")
+	}
+	return Load(c.source.path, c.source.overrides...)
+}
+
+// inheritExecutors decodes every executor again on top of a copy of the
+// sidecar section, so executors only need to set the fields that differ
+func inheritExecutors(data []byte, cfg *Config) error {
+	var raw struct {
+		Executors map[string]yaml.MapSlice `yaml:"executors"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	for name, fields := range raw.Executors {
+		encoded, err := yaml.Marshal(fields)
+		if err != nil {
+			return err
+		}
+
+		executor := cfg.Sidecar
+		executor.ClassRetries = make(map[string]int, len(cfg.Sidecar.ClassRetries))
+		for class, retries := range cfg.Sidecar.ClassRetries {
+			executor.ClassRetries[class] = retries
+		}
+		if err := yaml.Unmarshal(encoded, &executor); err != nil {
+			return fmt.Errorf("executors.%s: %w", name, err)
+		}
+		cfg.Executors[name] = executor
+	}
+	return nil
+}
+
+// expandEnvRefs parses the YAML document in data, expands environment
+// references in its string values and encodes it again. Comments are never
+// expanded, and values containing YAML syntax stay plain strings. Expanded
+// values YAML reads as a number or boolean are written back unquoted, so they
+// decode into typed fields like a literal would.
+func expandEnvRefs(data []byte) ([]byte, error) {
+	var tree yaml.MapSlice
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	if len(tree) == 0 {
+		return data, nil
+	}
+
+	var plain []string
+	encoded, err := yaml.Marshal(expandEnvValue(tree, &plain))
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range plain {
+		encoded = bytes.ReplaceAll(encoded, []byte(plainRef(i)), []byte(value))
+	}
+	return encoded, nil
+}
+
+// expandEnvValue expands the strings of a decoded YAML value. Expanded
+// numbers and booleans are replaced by a placeholder and appended to plain.
+func expandEnvValue(value interface{}, plain *[]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		expanded := expandEnv(v)
+		if expanded == v || !isTypedScalar(expanded) {
+			return expanded
+		}
+		*plain = append(*plain, expanded)
+		return plainRef(len(*plain) - 1)
+	case yaml.MapSlice:
+		for i := range v {
+			v[i].Value = expandEnvValue(v[i].Value, plain)
+		}
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			v[key] = expandEnvValue(item, plain)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = expandEnvValue(v[i], plain)
+		}
+	}
+	return value
+}
+
+// plainRef is the placeholder for the i-th expanded value written unquoted
+func plainRef(i int) string {
+	return fmt.Sprintf("__gokiq_env_%d__", i)
+}
+
+// isTypedScalar reports whether YAML reads value on its own as a number or boolean
+func isTypedScalar(value string) bool {
+	if strings.ContainsAny(value, " \t\r\n#") {
+		return false
+	}
+	var decoded interface{}
+	if err := yaml.Unmarshal([]byte(value), &decoded); err != nil {
+		return false
+	}
+	switch decoded.(type) {
+	case int, int64, uint64, float64, bool:
+		return true
+	}
+	return false
+}
+
+// expandEnv replaces ${VAR} and ${VAR:-default} references with environment values
+func expandEnv(content string) string {
+	return envRefPattern.ReplaceAllStringFunc(content, func(ref string) string {
+		match := envRefPattern.FindStringSubmatch(ref)
+		if value, ok := os.LookupEnv(match[1]); ok && value != "" {
+			return value
+		}
+		return match[2]
+	})
+}
+
+// applyEnv overrides every config field that has a matching environment variable
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	resolve := func(name string) (string, bool) {
+		if value, ok := lookup(name); ok {
+			return value, true
+		}
+		// Empty legacy variables are ignored, as they were before the GOKIQ_ ones
+		for legacy, current := range legacyEnv {
+			if current == name {
+				if value, ok := lookup(legacy); ok && value != "" {
+					return value, true
+				}
+			}
+		}
+		return "", false
+	}
+
+	return applyEnvStruct(reflect.ValueOf(cfg).Elem(), EnvPrefix, resolve)
+}
+
+// applyEnvStruct walks a config struct, deriving variable names from yaml tags
+func applyEnvStruct(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		name := prefix + "_" + strings.ToUpper(tag)
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Struct {
+			if err := applyEnvStruct(fv, name, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setField(fv, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setField parses an environment value into a config field
+func setField(fv reflect.Value, value string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("lists of %s cannot be set from the environment", fv.Type().Elem())
+		}
+		fv.Set(reflect.ValueOf(splitList(value)))
+	case reflect.Map:
+		return setMap(fv, value)
+	default:
+		return fmt.Errorf("%s fields cannot be set from the environment", fv.Type())
+	}
+	return nil
+}
+
+// setMap replaces a map field with "key=value" pairs separated by commas or
+// spaces, e.g. "critical=3,default=1". A value may itself contain "=".
+func setMap(fv reflect.Value, value string) error {
+	if fv.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("maps keyed by %s cannot be set from the environment", fv.Type().Key())
+	}
+
+	m := reflect.MakeMap(fv.Type())
+	for _, pair := range splitList(value) {
+		key, item, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("expected key=value, got %q", pair)
+		}
+		elem := reflect.New(fv.Type().Elem()).Elem()
+		if err := setField(elem, item); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		m.SetMapIndex(reflect.ValueOf(key).Convert(fv.Type().Key()), elem)
+	}
+	fv.Set(m)
+	return nil
+}
+
+// splitList parses a comma or space separated list
+func splitList(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if fields == nil {
+		return []string{}
+	}
+	return fields
+}
+
+// Flags holds command-line overrides for the configuration
+type Flags struct {
+	path        string
+	concurrency int
+	queues      string
+}
+
+// RegisterFlags adds the configuration flags to fs
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.StringVar(&f.path, "config", DefaultPath, "path to the YAML configuration file")
+	fs.IntVar(&f.concurrency, "concurrency", 0, "override worker.concurrency")
+	fs.StringVar(&f.queues, "queues", "", "override worker.queues (comma separated)")
+	return f
+}
+
+// Load loads the configuration with the flag values taking precedence over
+// the file and environment
+func (f *Flags) Load() (*Config, error) {
+	return Load(f.path, f.apply)
+}
+
+func (f *Flags) apply(cfg *Config) {
+	if f.concurrency != 0 {
+		cfg.Worker.Concurrency = f.concurrency
+	}
+	if f.queues != "" {
+		cfg.Worker.Queues = splitList(f.queues)
+	}
+}
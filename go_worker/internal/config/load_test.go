@@ -0,0 +1,351 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	path := writeConfig(t, "worker:\n  concurrency: 50\n")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Worker.Concurrency != 50 {
+		t.Errorf("Expected concurrency 50 from file, got %d", cfg.Worker.Concurrency)
+	}
+	if cfg.Worker.PollInterval != 100*time.Millisecond {
+		t.Errorf("Expected default poll interval, got %v", cfg.Worker.PollInterval)
+	}
+	if !reflect.DeepEqual(cfg.Worker.Queues, []string{"default"}) {
+		t.Errorf("Expected default queues, got %v", cfg.Worker.Queues)
+	}
+	if cfg.Sidecar.Timeout != 30*time.Second {
+		t.Errorf("Expected default sidecar timeout, got %v", cfg.Sidecar.Timeout)
+	}
+}
+
+func TestLoad_ExpandsEnvReferences(t *testing.T) {
+	t.Setenv("TEST_SIDECAR_HOST", "sidecar.internal")
+	path := writeConfig(t, `
+sidecar:
+  url: "http://${TEST_SIDECAR_HOST}:9292"
+redis:
+  url: "${TEST_UNSET_REDIS:-redis.internal:6379}"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Sidecar.URL != "http://sidecar.internal:9292" {
+		t.Errorf("Expected expanded sidecar URL, got %s", cfg.Sidecar.URL)
+	}
+	if cfg.Redis.URL != "redis.internal:6379" {
+		t.Errorf("Expected default from ${VAR:-default}, got %s", cfg.Redis.URL)
+	}
+}
+
+func TestLoad_ExpandsEnvReferencesInValuesOnly(t *testing.T) {
+	t.Setenv("TEST_ADMIN_TOKEN", "s3cr#t: \"quoted\"\nline")
+	t.Setenv("TEST_SIDECAR_TIMEOUT", "5s")
+	path := writeConfig(t, `
+# Set ${TEST_ADMIN_TOKEN} in production
+admin:
+  token: ${TEST_ADMIN_TOKEN}
+sidecar:
+  timeout: ${TEST_SIDECAR_TIMEOUT}
+executors:
+  reports:
+    url: "http://reports:9292"
+    timeout: ${TEST_UNSET_TIMEOUT:-10s}
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Admin.Token != "s3cr#t: \"quoted\"\nline" {
+		t.Errorf("Expected the token verbatim, got %q", cfg.Admin.Token)
+	}
+	if cfg.Sidecar.Timeout != 5*time.Second {
+		t.Errorf("Expected expanded sidecar timeout, got %v", cfg.Sidecar.Timeout)
+	}
+	if cfg.Executors["reports"].Timeout != 10*time.Second {
+		t.Errorf("Expected expanded executor timeout, got %v", cfg.Executors["reports"].Timeout)
+	}
+}
+
+func TestLoad_ExpandsTypedEnvReferences(t *testing.T) {
+	t.Setenv("TEST_CONCURRENCY", "42")
+	t.Setenv("TEST_ADMIN_ENABLED", "true")
+	t.Setenv("TEST_FAILURE_RATE", "0.25")
+	t.Setenv("TEST_ADMIN_TOKEN", "0123")
+	path := writeConfig(t, `
+worker:
+  concurrency: ${TEST_CONCURRENCY:-20}
+  queues: ["critical", "default"]
+  weights:
+    critical: ${TEST_UNSET_WEIGHT:-3}
+admin:
+  enabled: ${TEST_ADMIN_ENABLED}
+  token: ${TEST_ADMIN_TOKEN}
+sidecar:
+  breaker:
+    failure_rate: ${TEST_FAILURE_RATE}
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Worker.Concurrency != 42 || cfg.Worker.Weights["critical"] != 3 {
+		t.Errorf("Expected expanded ints, got concurrency=%d weights=%v", cfg.Worker.Concurrency, cfg.Worker.Weights)
+	}
+	if !cfg.Admin.Enabled || cfg.Sidecar.Breaker.FailureRate != 0.25 {
+		t.Errorf("Expected expanded bool and float, got enabled=%t rate=%v", cfg.Admin.Enabled, cfg.Sidecar.Breaker.FailureRate)
+	}
+	if cfg.Admin.Token != "0123" {
+		t.Errorf("Expected a numeric-looking string verbatim, got %q", cfg.Admin.Token)
+	}
+}
+
+func TestLoad_EnvOverridesMaps(t *testing.T) {
+	t.Setenv("GOKIQ_WORKER_WEIGHTS", "critical=3, default=1")
+	t.Setenv("GOKIQ_EXPIRATION_TTLS", "NotifyJob=1h")
+	t.Setenv("GOKIQ_ENCRYPTION_KEYS", "k1=c2VjcmV0LWtleS0xMjM0NQ==")
+	path := writeConfig(t, "worker:\n  queues: [critical, default, low]\n  weights:\n    low: 1\n")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Worker.Weights, map[string]int{"critical": 3, "default": 1}) {
+		t.Errorf("Expected weights from env to replace the file's, got %v", cfg.Worker.Weights)
+	}
+	if cfg.Expiration.TTLs["NotifyJob"] != time.Hour {
+		t.Errorf("Expected TTLs from env, got %v", cfg.Expiration.TTLs)
+	}
+	if cfg.Encryption.Keys["k1"] != "c2VjcmV0LWtleS0xMjM0NQ==" {
+		t.Errorf("Expected keys containing = to be kept whole, got %v", cfg.Encryption.Keys)
+	}
+
+	t.Setenv("GOKIQ_WORKER_WEIGHTS", "critical")
+	if _, err := Load(path); err == nil {
+		t.Error("Expected error for a pair without =")
+	}
+	t.Setenv("GOKIQ_WORKER_WEIGHTS", "")
+	t.Setenv("GOKIQ_EXECUTORS", "reports=http://reports:9292")
+	if _, err := Load(path); err == nil {
+		t.Error("Expected error for a field that cannot be set from the environment")
+	}
+}
+
+func TestLoad_EnvOverrides(t *testing.T) {
+	t.Setenv("GOKIQ_WORKER_POLL_INTERVAL", "250ms")
+	t.Setenv("GOKIQ_WORKER_QUEUES", "critical, default")
+	t.Setenv("GOKIQ_ADMIN_ENABLED", "true")
+	t.Setenv("GOKIQ_RETRY_MAX_ATTEMPTS", "5")
+	t.Setenv("WORKER_CONCURRENCY", "7")
+	t.Setenv("REDIS_URL", "redis://legacy:6379/0")
+	t.Setenv("GOKIQ_REDIS_URL", "redis://current:6379/0")
+	path := writeConfig(t, "worker:\n  concurrency: 50\n  poll_interval: 1s\n")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Worker.PollInterval != 250*time.Millisecond {
+		t.Errorf("Expected poll interval from env, got %v", cfg.Worker.PollInterval)
+	}
+	if !reflect.DeepEqual(cfg.Worker.Queues, []string{"critical", "default"}) {
+		t.Errorf("Expected queues from env, got %v", cfg.Worker.Queues)
+	}
+	if !cfg.Admin.Enabled {
+		t.Error("Expected admin to be enabled from env")
+	}
+	if cfg.Retry.MaxAttempts != 5 {
+		t.Errorf("Expected max attempts from env, got %d", cfg.Retry.MaxAttempts)
+	}
+	if cfg.Worker.Concurrency != 7 {
+		t.Errorf("Expected legacy WORKER_CONCURRENCY to apply, got %d", cfg.Worker.Concurrency)
+	}
+	if cfg.Redis.URL != "redis://current:6379/0" {
+		t.Errorf("Expected GOKIQ_REDIS_URL to win over REDIS_URL, got %s", cfg.Redis.URL)
+	}
+}
+
+func TestLoad_IgnoresEmptyLegacyEnv(t *testing.T) {
+	t.Setenv("REDIS_URL", "")
+	t.Setenv("WORKER_CONCURRENCY", "")
+	path := writeConfig(t, "redis:\n  url: redis://file:6379/0\nworker:\n  concurrency: 50\n")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Redis.URL != "redis://file:6379/0" || cfg.Worker.Concurrency != 50 {
+		t.Errorf("Expected empty legacy variables to be ignored, got url=%s concurrency=%d", cfg.Redis.URL, cfg.Worker.Concurrency)
+	}
+}
+
+func TestLoad_InvalidEnvValue(t *testing.T) {
+	t.Setenv("GOKIQ_WORKER_CONCURRENCY", "many")
+	path := writeConfig(t, "")
+
+	if _, err := Load(path); err == nil {
+		t.Fatal("Expected error for non-numeric concurrency")
+	}
+}
+
+func TestLoad_Flags(t *testing.T) {
+	t.Setenv("GOKIQ_WORKER_CONCURRENCY", "7")
+	path := writeConfig(t, "worker:\n  concurrency: 50\n")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := RegisterFlags(fs)
+	if err := fs.Parse([]string{"--config", path, "-concurrency", "3", "-queues", "a,b"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	cfg, err := flags.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Worker.Concurrency != 3 {
+		t.Errorf("Expected flag to win over env, got %d", cfg.Worker.Concurrency)
+	}
+	if !reflect.DeepEqual(cfg.Worker.Queues, []string{"a", "b"}) {
+		t.Errorf("Expected queues from flag, got %v", cfg.Worker.Queues)
+	}
+}
+
+func TestLoad_ReportsEveryInvalidField(t *testing.T) {
+	path := writeConfig(t, `
+sidecar:
+  url: "rails_sidecar:9292"
+  timeout: 0s
+worker:
+  concurrency: 0
+  poll_interval: 0s
+`)
+
+	_, err := Load(path)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+
+	fields := map[string]bool{}
+	for _, fe := range verr.Errors {
+		fields[fe.Field] = true
+	}
+	for _, field := range []string{"sidecar.url", "sidecar.timeout", "worker.concurrency", "worker.poll_interval"} {
+		if !fields[field] {
+			t.Errorf("Expected %s to be reported, got %v", field, verr.Errors)
+		}
+	}
+}
+
+func TestLoad_ExecutorsInheritSidecar(t *testing.T) {
+	path := writeConfig(t, `
+sidecar:
+  url: "http://rails:9292"
+  timeout: 20s
+  retries: 2
+executors:
+  billing:
+    url: "http://billing:9292"
+    retries: 0
+    breaker:
+      failure_threshold: 3
+routes:
+  - executor: billing
+    classes: ["Billing::*"]
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	billing := cfg.Executors["billing"]
+	if billing.URL != "http://billing:9292" || billing.Retries != 0 || billing.Breaker.FailureThreshold != 3 {
+		t.Errorf("Expected executor's own settings, got %+v", billing)
+	}
+	if billing.Timeout != 20*time.Second {
+		t.Errorf("Expected timeout inherited from sidecar, got %v", billing.Timeout)
+	}
+	if billing.Breaker.ResetTimeout != 30*time.Second {
+		t.Errorf("Expected unset breaker fields inherited from sidecar, got %+v", billing.Breaker)
+	}
+	if len(cfg.Routes) != 1 || cfg.Routes[0].Classes[0] != "Billing::*" {
+		t.Errorf("Expected route to be loaded, got %+v", cfg.Routes)
+	}
+}
+
+func TestLoad_DerivesPools(t *testing.T) {
+	path := writeConfig(t, `
+worker:
+  concurrency: 200
+sidecar:
+  pool:
+    max_idle_conns: 50
+executors:
+  billing:
+    url: "http://billing:9292"
+    pool:
+      max_conns: 20
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Redis.Pool.Size != 60 {
+		t.Errorf("Expected Redis pool of 10 + 200/4, got %d", cfg.Redis.Pool.Size)
+	}
+	if pool := cfg.Sidecar.Pool; pool.MaxConns != 200 || pool.MaxIdleConns != 50 {
+		t.Errorf("Expected one sidecar connection per job and the configured idle limit, got %+v", pool)
+	}
+	if pool := cfg.Executors["billing"].Pool; pool.MaxConns != 20 || pool.MaxIdleConns != 50 {
+		t.Errorf("Expected executor's own max_conns and inherited idle limit, got %+v", pool)
+	}
+
+	cfg.Worker.Adaptive = AdaptiveConfig{Enabled: true, Max: 400}
+	cfg.Redis.Pool.Size = 0
+	cfg.DerivePools()
+	if cfg.Redis.Pool.Size != 110 {
+		t.Errorf("Expected Redis pool derived from adaptive max, got %d", cfg.Redis.Pool.Size)
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("Expected error for missing file")
+	}
+}
@@ -0,0 +1,373 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"gokiq/internal/cron"
+)
+
+// FieldError describes a single invalid configuration field
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationError lists every invalid field found in a configuration
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return fmt.Sprintf("invalid configuration:\n  %s", strings.Join(msgs, "\n  "))
+}
+
+// add records an invalid field
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks every field and reports all problems at once
+func (c *Config) Validate() error {
+	verr := &ValidationError{}
+
+	validateRedis(verr, c.Redis)
+
+	validateSidecar(verr, "sidecar", c.Sidecar)
+	for name, executor := range c.Executors {
+		validateSidecar(verr, "executors."+name, executor)
+	}
+	for i, route := range c.Routes {
+		field := fmt.Sprintf("routes[%d]", i)
+		if _, ok := c.Executors[route.Executor]; !ok {
+			verr.add(field+".executor", "must name an entry in executors, got %q", route.Executor)
+		}
+		if len(route.Classes) == 0 && len(route.Queues) == 0 {
+			verr.add(field, "must list classes or queues")
+		}
+		for j, pattern := range route.Classes {
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				verr.add(fmt.Sprintf("%s.classes[%d]", field, j), "must be a valid pattern, got %q", pattern)
+			}
+		}
+	}
+
+	if c.Worker.Concurrency < 1 {
+		verr.add("worker.concurrency", "must be at least 1, got %d", c.Worker.Concurrency)
+	}
+	if len(c.Worker.Queues) == 0 {
+		verr.add("worker.queues", "must list at least one queue")
+	}
+	for i, queue := range c.Worker.Queues {
+		if strings.TrimSpace(queue) == "" {
+			verr.add(fmt.Sprintf("worker.queues[%d]", i), "must not be empty")
+		}
+	}
+	for queue, weight := range c.Worker.Weights {
+		if weight < 1 {
+			verr.add(fmt.Sprintf("worker.weights.%s", queue), "must be at least 1, got %d", weight)
+		}
+		if !containsString(c.Worker.Queues, queue) {
+			verr.add(fmt.Sprintf("worker.weights.%s", queue), "is not listed in worker.queues")
+		}
+	}
+	for i, name := range c.Worker.Middleware {
+		if strings.TrimSpace(name) == "" {
+			verr.add(fmt.Sprintf("worker.middleware[%d]", i), "must not be empty")
+		} else if containsString(c.Worker.Middleware[:i], name) {
+			verr.add(fmt.Sprintf("worker.middleware[%d]", i), "duplicates %q", name)
+		}
+	}
+	if c.Worker.PollInterval <= 0 {
+		verr.add("worker.poll_interval", "must be positive, got %v", c.Worker.PollInterval)
+	}
+	if a := c.Worker.Adaptive; a.Enabled {
+		if a.Min < 1 {
+			verr.add("worker.adaptive.min", "must be at least 1, got %d", a.Min)
+		}
+		if a.Max < a.Min {
+			verr.add("worker.adaptive.max", "must be at least worker.adaptive.min (%d), got %d", a.Min, a.Max)
+		}
+		if a.Interval <= 0 {
+			verr.add("worker.adaptive.interval", "must be positive, got %v", a.Interval)
+		}
+		if a.LatencyTolerance < 1 {
+			verr.add("worker.adaptive.latency_tolerance", "must be at least 1, got %v", a.LatencyTolerance)
+		}
+		if a.MaxErrorRate < 0 || a.MaxErrorRate > 1 {
+			verr.add("worker.adaptive.max_error_rate", "must be between 0 and 1, got %v", a.MaxErrorRate)
+		}
+		if a.Backoff <= 0 || a.Backoff >= 1 {
+			verr.add("worker.adaptive.backoff", "must be between 0 and 1 (exclusive), got %v", a.Backoff)
+		}
+	}
+
+	if c.Retry.MaxAttempts < 0 {
+		verr.add("retry.max_attempts", "must not be negative, got %d", c.Retry.MaxAttempts)
+	}
+	if c.Retry.BaseDelay <= 0 {
+		verr.add("retry.base_delay", "must be positive, got %v", c.Retry.BaseDelay)
+	}
+	if c.Retry.MaxDelay < c.Retry.BaseDelay {
+		verr.add("retry.max_delay", "must be at least retry.base_delay (%v), got %v", c.Retry.BaseDelay, c.Retry.MaxDelay)
+	}
+
+	validateCron(verr, c.Cron)
+	validateBlob(verr, c.Blob)
+	validateEncryption(verr, c.Encryption)
+
+	for class, ttl := range c.Expiration.TTLs {
+		if ttl <= 0 {
+			verr.add("expiration.ttls."+class, "must be positive, got %v", ttl)
+		}
+	}
+
+	if c.Admin.Enabled && c.Admin.Addr == "" {
+		verr.add("admin.addr", "must be set when the admin API is enabled")
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// validateRedis checks the Redis connection settings for the selected mode
+func validateRedis(verr *ValidationError, r RedisConfig) {
+	sentinel, cluster := r.Sentinel.MasterName != "", len(r.Cluster.Addrs) > 0
+
+	switch {
+	case sentinel && cluster:
+		verr.add("redis", "must not configure both sentinel and cluster")
+	case sentinel:
+		if len(r.Sentinel.Addrs) == 0 {
+			verr.add("redis.sentinel.addrs", "must list at least one sentinel")
+		}
+	case cluster:
+		if r.DB != 0 {
+			verr.add("redis.db", "must be 0 with Redis Cluster, got %d", r.DB)
+		}
+		if r.Cluster.HashTag == "" || strings.ContainsAny(r.Cluster.HashTag, "{}") {
+			verr.add("redis.cluster.hash_tag", "must be non-empty and not contain braces, got %q", r.Cluster.HashTag)
+		}
+	default:
+		if r.URL == "" {
+			verr.add("redis.url", "must not be empty")
+		} else if i := strings.Index(r.URL, "://"); i >= 0 && !containsString([]string{"redis", "rediss", "unix"}, r.URL[:i]) {
+			verr.add("redis.url", "must use redis://, rediss:// or unix://, got %q", r.URL)
+		}
+	}
+	if r.DB < 0 {
+		verr.add("redis.db", "must not be negative, got %d", r.DB)
+	}
+	if strings.ContainsAny(r.Namespace, "{} \t\n") {
+		verr.add("redis.namespace", "must not contain braces or whitespace, got %q", r.Namespace)
+	}
+	if r.Password != "" && r.PasswordFile != "" {
+		verr.add("redis.password_file", "must not be set together with redis.password")
+	}
+	if (r.TLS.CertFile == "") != (r.TLS.KeyFile == "") {
+		verr.add("redis.tls.key_file", "must be set together with redis.tls.cert_file")
+	}
+
+	pool := r.Pool
+	if pool.Size < 0 {
+		verr.add("redis.pool.size", "must not be negative, got %d", pool.Size)
+	}
+	if pool.MinIdle < 0 {
+		verr.add("redis.pool.min_idle", "must not be negative, got %d", pool.MinIdle)
+	} else if pool.Size > 0 && pool.MinIdle > pool.Size {
+		verr.add("redis.pool.min_idle", "must not exceed redis.pool.size (%d), got %d", pool.Size, pool.MinIdle)
+	}
+	if pool.MaxRetries < 0 {
+		verr.add("redis.pool.max_retries", "must not be negative, got %d", pool.MaxRetries)
+	}
+	timeouts := []struct {
+		field   string
+		timeout time.Duration
+	}{
+		{"dial_timeout", pool.DialTimeout},
+		{"read_timeout", pool.ReadTimeout},
+		{"write_timeout", pool.WriteTimeout},
+		{"pool_timeout", pool.PoolTimeout},
+	}
+	for _, t := range timeouts {
+		if t.timeout <= 0 {
+			verr.add("redis.pool."+t.field, "must be positive, got %v", t.timeout)
+		}
+	}
+	if pool.IdleTimeout < 0 {
+		verr.add("redis.pool.idle_timeout", "must not be negative, got %v", pool.IdleTimeout)
+	}
+
+	for i, addr := range r.Sentinel.Addrs {
+		if strings.TrimSpace(addr) == "" {
+			verr.add(fmt.Sprintf("redis.sentinel.addrs[%d]", i), "must not be empty")
+		}
+	}
+	for i, addr := range r.Cluster.Addrs {
+		if strings.TrimSpace(addr) == "" {
+			verr.add(fmt.Sprintf("redis.cluster.addrs[%d]", i), "must not be empty")
+		}
+	}
+}
+
+// validateSidecar checks the settings of one sidecar, reported under prefix
+func validateSidecar(verr *ValidationError, prefix string, sidecar SidecarConfig) {
+	if u, err := url.Parse(sidecar.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.add(prefix+".url", "must be an http(s) URL, got %q", sidecar.URL)
+	}
+	if sidecar.Timeout <= 0 {
+		verr.add(prefix+".timeout", "must be positive, got %v", sidecar.Timeout)
+	}
+	if sidecar.Retries < 0 {
+		verr.add(prefix+".retries", "must not be negative, got %d", sidecar.Retries)
+	}
+	for class, retries := range sidecar.ClassRetries {
+		if retries < 0 {
+			verr.add(fmt.Sprintf("%s.class_retries.%s", prefix, class), "must not be negative, got %d", retries)
+		}
+	}
+
+	if sidecar.Pool.MaxConns < 0 {
+		verr.add(prefix+".pool.max_conns", "must not be negative, got %d", sidecar.Pool.MaxConns)
+	}
+	if sidecar.Pool.MaxIdleConns < 0 {
+		verr.add(prefix+".pool.max_idle_conns", "must not be negative, got %d", sidecar.Pool.MaxIdleConns)
+	}
+	if sidecar.Pool.IdleConnTimeout <= 0 {
+		verr.add(prefix+".pool.idle_conn_timeout", "must be positive, got %v", sidecar.Pool.IdleConnTimeout)
+	}
+	if sidecar.Pool.DialTimeout <= 0 {
+		verr.add(prefix+".pool.dial_timeout", "must be positive, got %v", sidecar.Pool.DialTimeout)
+	}
+
+	breaker := sidecar.Breaker
+	if breaker.FailureThreshold < 1 {
+		verr.add(prefix+".breaker.failure_threshold", "must be at least 1, got %d", breaker.FailureThreshold)
+	}
+	if breaker.FailureRate < 0 || breaker.FailureRate > 1 {
+		verr.add(prefix+".breaker.failure_rate", "must be between 0 and 1, got %v", breaker.FailureRate)
+	}
+	if breaker.Window <= 0 {
+		verr.add(prefix+".breaker.window", "must be positive, got %v", breaker.Window)
+	}
+	if breaker.ResetTimeout <= 0 {
+		verr.add(prefix+".breaker.reset_timeout", "must be positive, got %v", breaker.ResetTimeout)
+	}
+	if breaker.HalfOpenProbes < 1 {
+		verr.add(prefix+".breaker.half_open_probes", "must be at least 1, got %d", breaker.HalfOpenProbes)
+	}
+}
+
+// validateBlob checks the settings of the selected blob store backend
+func validateBlob(verr *ValidationError, b BlobConfig) {
+	if b.Threshold < 0 {
+		verr.add("blob.threshold", "must not be negative, got %d", b.Threshold)
+	}
+
+	switch b.Backend {
+	case "":
+	case "local":
+		if b.Local.Dir == "" {
+			verr.add("blob.local.dir", "must be set for the local backend")
+		}
+	case "s3":
+		if u, err := url.Parse(b.S3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			verr.add("blob.s3.endpoint", "must be an http(s) URL, got %q", b.S3.Endpoint)
+		}
+		if b.S3.Bucket == "" || strings.Contains(b.S3.Bucket, "/") {
+			verr.add("blob.s3.bucket", "must be a bucket name, got %q", b.S3.Bucket)
+		}
+		if b.S3.Region == "" {
+			verr.add("blob.s3.region", "must be set for the s3 backend")
+		}
+		if b.S3.AccessKeyID == "" || b.S3.SecretAccessKey == "" {
+			verr.add("blob.s3.access_key_id", "and blob.s3.secret_access_key must both be set")
+		}
+		if b.S3.Timeout <= 0 {
+			verr.add("blob.s3.timeout", "must be positive, got %v", b.S3.Timeout)
+		}
+	default:
+		verr.add("blob.backend", "must be \"local\" or \"s3\", got %q", b.Backend)
+	}
+}
+
+// validateEncryption checks that every key decodes to an AES key and that
+// the current key is one of them
+func validateEncryption(verr *ValidationError, e EncryptionConfig) {
+	if !e.Enabled {
+		return
+	}
+	if _, ok := e.Keys[e.Current]; !ok {
+		verr.add("encryption.current", "must name a key in encryption.keys, got %q", e.Current)
+	}
+	for id, encoded := range e.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || (len(key) != 16 && len(key) != 24 && len(key) != 32) {
+			verr.add("encryption.keys."+id, "must be a base64-encoded 16, 24 or 32 byte key")
+		}
+	}
+}
+
+// validateCron checks the cron section and every periodic job
+func validateCron(verr *ValidationError, c CronConfig) {
+	if c.LeaderTTL < time.Second {
+		verr.add("cron.leader_ttl", "must be at least 1s, got %v", c.LeaderTTL)
+	}
+	if c.Grace <= 0 {
+		verr.add("cron.grace", "must be positive, got %v", c.Grace)
+	}
+	if !cron.ValidPolicy(c.CatchUp) {
+		verr.add("cron.catch_up", "must be one of skip, last, all, got %q", c.CatchUp)
+	}
+	if c.MaxCatchUp < 1 {
+		verr.add("cron.max_catch_up", "must be at least 1, got %d", c.MaxCatchUp)
+	}
+
+	names := make(map[string]bool, len(c.Jobs))
+	for i, entry := range c.Jobs {
+		field := fmt.Sprintf("cron.jobs[%d]", i)
+		switch {
+		case strings.TrimSpace(entry.Name) == "":
+			verr.add(field+".name", "must not be empty")
+		case names[entry.Name]:
+			verr.add(field+".name", "duplicates %q", entry.Name)
+		}
+		names[entry.Name] = true
+
+		if entry.Class == "" {
+			verr.add(field+".class", "must not be empty")
+		}
+		if _, err := cron.Parse(entry.Schedule); err != nil {
+			verr.add(field+".schedule", "%v", err)
+		}
+		if _, err := time.LoadLocation(entry.Timezone); err != nil {
+			verr.add(field+".timezone", "must be an IANA time zone, got %q", entry.Timezone)
+		}
+		if entry.CatchUp != "" && !cron.ValidPolicy(entry.CatchUp) {
+			verr.add(field+".catch_up", "must be one of skip, last, all, got %q", entry.CatchUp)
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+		field  string
+	}{
+		{"empty redis url", func(c *Config) { c.Redis.URL = "" }, "redis.url"},
+		{"sentinel without addrs", func(c *Config) { c.Redis.Sentinel.MasterName = "mymaster" }, "redis.sentinel.addrs"},
+		{"cluster with db", func(c *Config) { c.Redis.Cluster.Addrs = []string{"redis-1:7000"}; c.Redis.DB = 1 }, "redis.db"},
+		{"cluster hash tag with braces", func(c *Config) {
+			c.Redis.Cluster.Addrs = []string{"redis-1:7000"}
+			c.Redis.Cluster.HashTag = "{gokiq}"
+		}, "redis.cluster.hash_tag"},
+		{"sentinel and cluster", func(c *Config) {
+			c.Redis.Sentinel = SentinelConfig{MasterName: "mymaster", Addrs: []string{"sentinel:26379"}}
+			c.Redis.Cluster.Addrs = []string{"redis-1:7000"}
+		}, "redis"},
+		{"redis url with unknown scheme", func(c *Config) { c.Redis.URL = "http://redis:6379" }, "redis.url"},
+		{"password and password file", func(c *Config) { c.Redis.Password = "secret"; c.Redis.PasswordFile = "/run/secrets/redis" }, "redis.password_file"},
+		{"client certificate without key", func(c *Config) { c.Redis.TLS.CertFile = "/etc/redis/client.crt" }, "redis.tls.key_file"},
+		{"redis min idle above pool size", func(c *Config) { c.Redis.Pool.Size = 2 }, "redis.pool.min_idle"},
+		{"zero redis pool timeout", func(c *Config) { c.Redis.Pool.PoolTimeout = 0 }, "redis.pool.pool_timeout"},
+		{"negative sidecar max conns", func(c *Config) { c.Sidecar.Pool.MaxConns = -1 }, "sidecar.pool.max_conns"},
+		{"namespace with braces", func(c *Config) { c.Redis.Namespace = "{billing}" }, "redis.namespace"},
+		{"unknown blob backend", func(c *Config) { c.Blob.Backend = "gcs" }, "blob.backend"},
+		{"local blob store without dir", func(c *Config) { c.Blob.Backend = "local" }, "blob.local.dir"},
+		{"s3 blob store without credentials", func(c *Config) {
+			c.Blob.Backend = "s3"
+			c.Blob.S3.Endpoint = "https://s3.amazonaws.com"
+			c.Blob.S3.Bucket = "jobs"
+		}, "blob.s3.access_key_id"},
+		{"encryption without current key", func(c *Config) {
+			c.Encryption = EncryptionConfig{Enabled: true, Keys: map[string]string{"k1": "AAAAAAAAAAAAAAAAAAAAAA=="}}
+		}, "encryption.current"},
+		{"encryption key of wrong length", func(c *Config) {
+			c.Encryption = EncryptionConfig{Enabled: true, Current: "k1", Keys: map[string]string{"k1": "c2hvcnQ="}}
+		}, "encryption.keys.k1"},
+		{"zero class ttl", func(c *Config) { c.Expiration.TTLs = map[string]time.Duration{"NotifyJob": 0} }, "expiration.ttls.NotifyJob"},
+		{"negative redis db", func(c *Config) { c.Redis.DB = -1 }, "redis.db"},
+		{"sidecar url without scheme", func(c *Config) { c.Sidecar.URL = "localhost:9292" }, "sidecar.url"},
+		{"zero sidecar timeout", func(c *Config) { c.Sidecar.Timeout = 0 }, "sidecar.timeout"},
+		{"negative class retries", func(c *Config) { c.Sidecar.ClassRetries = map[string]int{"ChargeJob": -1} }, "sidecar.class_retries.ChargeJob"},
+		{"breaker failure rate above one", func(c *Config) { c.Sidecar.Breaker.FailureRate = 1.5 }, "sidecar.breaker.failure_rate"},
+		{"no half-open probes", func(c *Config) { c.Sidecar.Breaker.HalfOpenProbes = 0 }, "sidecar.breaker.half_open_probes"},
+		{"executor without url", func(c *Config) {
+			billing := c.Sidecar
+			billing.URL = ""
+			c.Executors = map[string]SidecarConfig{"billing": billing}
+		}, "executors.billing.url"},
+		{"route to unknown executor", func(c *Config) { c.Routes = []RouteConfig{{Executor: "billing", Queues: []string{"billing"}}} }, "routes[0].executor"},
+		{"route without matchers", func(c *Config) {
+			c.Executors = map[string]SidecarConfig{"billing": c.Sidecar}
+			c.Routes = []RouteConfig{{Executor: "billing"}}
+		}, "routes[0]"},
+		{"route with bad pattern", func(c *Config) {
+			c.Executors = map[string]SidecarConfig{"billing": c.Sidecar}
+			c.Routes = []RouteConfig{{Executor: "billing", Classes: []string{"Billing::["}}}
+		}, "routes[0].classes[0]"},
+		{"zero concurrency", func(c *Config) { c.Worker.Concurrency = 0 }, "worker.concurrency"},
+		{"no queues", func(c *Config) { c.Worker.Queues = nil }, "worker.queues"},
+		{"blank queue", func(c *Config) { c.Worker.Queues = []string{"default", " "} }, "worker.queues[1]"},
+		{"duplicate middleware", func(c *Config) { c.Worker.Middleware = []string{"recover", "recover"} }, "worker.middleware[1]"},
+		{"zero poll interval", func(c *Config) { c.Worker.PollInterval = 0 }, "worker.poll_interval"},
+		{"adaptive max below min", func(c *Config) { c.Worker.Adaptive.Enabled = true; c.Worker.Adaptive.Max = 0 }, "worker.adaptive.max"},
+		{"adaptive backoff of one", func(c *Config) { c.Worker.Adaptive.Enabled = true; c.Worker.Adaptive.Backoff = 1 }, "worker.adaptive.backoff"},
+		{"negative max attempts", func(c *Config) { c.Retry.MaxAttempts = -1 }, "retry.max_attempts"},
+		{"zero base delay", func(c *Config) { c.Retry.BaseDelay = 0 }, "retry.base_delay"},
+		{"max delay below base", func(c *Config) { c.Retry.MaxDelay = time.Second }, "retry.max_delay"},
+		{"unknown cron catch-up policy", func(c *Config) { c.Cron.CatchUp = "sometimes" }, "cron.catch_up"},
+		{"cron job with bad schedule", func(c *Config) {
+			c.Cron.Jobs = []CronJobConfig{{Name: "report", Class: "ReportJob", Schedule: "61 * * * *"}}
+		}, "cron.jobs[0].schedule"},
+		{"cron job with unknown timezone", func(c *Config) {
+			c.Cron.Jobs = []CronJobConfig{{Name: "report", Class: "ReportJob", Schedule: "@daily", Timezone: "Mars/Olympus"}}
+		}, "cron.jobs[0].timezone"},
+		{"duplicate cron job", func(c *Config) {
+			entry := CronJobConfig{Name: "report", Class: "ReportJob", Schedule: "@daily"}
+			c.Cron.Jobs = []CronJobConfig{entry, entry}
+		}, "cron.jobs[1].name"},
+		{"admin without addr", func(c *Config) { c.Admin.Enabled = true; c.Admin.Addr = "" }, "admin.addr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			verr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Expected ValidationError, got %v", err)
+			}
+			if len(verr.Errors) != 1 || verr.Errors[0].Field != tt.field {
+				t.Errorf("Expected single error for %s, got %v", tt.field, verr.Errors)
+			}
+		})
+	}
+}
+
+func TestValidate_Default(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("Default config should be valid: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package cron
+
+import (
+	"time"
+
+	"gokiq/internal/job"
+)
+
+// Store persists the cron leader lock and the last tick of every periodic job
+type Store interface {
+	// AcquireCronLeader takes or renews the leader lock, reporting whether
+	// identity holds it
+	AcquireCronLeader(identity string, ttl time.Duration) (bool, error)
+
+	// ReleaseCronLeader gives up the leader lock if identity holds it
+	ReleaseCronLeader(identity string) error
+
+	// CronLastTick returns the Unix time of the last recorded tick, or 0
+	CronLastTick(name string) (int64, error)
+
+	// EnqueueCronTicks moves the last tick from previous to tick and pushes
+	// jobs atomically, reporting false if another process moved it first
+	EnqueueCronTicks(name string, previous, tick int64, jobs []*job.SidekiqJob) (bool, error)
+}
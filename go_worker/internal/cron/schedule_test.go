@@ -0,0 +1,57 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC), time.Date(2024, 1, 1, 10, 1, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 1, 10, 14, 0, 0, time.UTC), time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC), time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17 * * mon-fri", time.Date(2024, 1, 5, 17, 0, 0, 0, time.UTC), time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 2, 30, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 0", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		// 02:30 does not exist on the spring-forward day
+		{"30 2 * * *", time.Date(2024, 3, 10, 0, 0, 0, 0, newYork), time.Date(2024, 3, 11, 2, 30, 0, 0, newYork)},
+		// 01:30 happens twice on the fall-back day but runs once
+		{"30 1 * * *", time.Date(2024, 11, 3, 1, 30, 0, 0, newYork), time.Date(2024, 11, 4, 1, 30, 0, 0, newYork)},
+		{"0 9 * * *", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 13, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.expr, err)
+		}
+		from := tt.from
+		if tt.expr == "0 9 * * *" {
+			from = from.In(newYork)
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q, %v) = %v, want %v", tt.expr, from, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "@often"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	// Embed the time zone database so timezones resolve in minimal containers
+	_ "time/tzdata"
+
+	"gokiq/internal/job"
+)
+
+// Catch-up policies for ticks missed while no leader was running
+const (
+	// CatchUpSkip drops missed ticks, enqueuing only ticks within the grace period
+	CatchUpSkip = "skip"
+
+	// CatchUpLast enqueues the most recent tick once, however late it is
+	CatchUpLast = "last"
+
+	// CatchUpAll enqueues every missed tick, up to the scheduler's limit
+	CatchUpAll = "all"
+)
+
+// checkInterval is how often the scheduler renews leadership and looks for due ticks
+const checkInterval = time.Second
+
+// ValidPolicy reports whether policy is a known catch-up policy
+func ValidPolicy(policy string) bool {
+	return policy == CatchUpSkip || policy == CatchUpLast || policy == CatchUpAll
+}
+
+// Entry is a periodic job
+type Entry struct {
+	Name     string
+	Class    string
+	Queue    string
+	Args     []interface{}
+	Schedule *Schedule
+	Location *time.Location
+	CatchUp  string
+}
+
+// NewEntry builds a periodic job from its configuration, evaluating schedule
+// in the named time zone (UTC when empty)
+func NewEntry(name, class, queue string, args []interface{}, schedule, timezone, catchUp string) (Entry, error) {
+	parsed, err := Parse(schedule)
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid schedule for %s: %w", name, err)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid timezone for %s: %w", name, err)
+	}
+	if !ValidPolicy(catchUp) {
+		return Entry{}, fmt.Errorf("invalid catch-up policy for %s: %q", name, catchUp)
+	}
+	if queue == "" {
+		queue = "default"
+	}
+
+	return Entry{
+		Name:     name,
+		Class:    class,
+		Queue:    queue,
+		Args:     jsonArgs(args),
+		Schedule: parsed,
+		Location: location,
+		CatchUp:  catchUp,
+	}, nil
+}
+
+// Scheduler enqueues periodic jobs. Every worker process runs one, but only
+// the process holding the Redis leader lock enqueues. Ticks are identified by
+// their scheduled time and recorded with a compare-and-set, so a tick is
+// enqueued at most once across leader changes, restarts and clock skew: a
+// leader whose clock lags the previous one finds its ticks already recorded.
+type Scheduler struct {
+	store      Store
+	identity   string
+	entries    []Entry
+	leaderTTL  time.Duration
+	grace      time.Duration
+	maxCatchUp int
+	now        func() time.Time
+
+	leader bool
+	mu     sync.Mutex
+}
+
+// NewScheduler creates a scheduler for entries. The leader lock expires after
+// leaderTTL unless renewed, ticks older than grace count as missed, and at most
+// maxCatchUp missed ticks are enqueued per job under CatchUpAll.
+func NewScheduler(store Store, identity string, entries []Entry, leaderTTL, grace time.Duration, maxCatchUp int) *Scheduler {
+	return &Scheduler{
+		store:      store,
+		identity:   identity,
+		entries:    entries,
+		leaderTTL:  leaderTTL,
+		grace:      grace,
+		maxCatchUp: maxCatchUp,
+		now:        time.Now,
+	}
+}
+
+// Run checks for due ticks every second until ctx is cancelled, then gives up
+// leadership so another process can take over immediately
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(); err != nil {
+			log.Printf("Cron error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if s.IsLeader() {
+				if err := s.store.ReleaseCronLeader(s.identity); err != nil {
+					log.Printf("Cron error: %v", err)
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsLeader reports whether this process held the leader lock at the last check
+func (s *Scheduler) IsLeader() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leader
+}
+
+// Tick renews leadership and, while leader, enqueues every due tick
+func (s *Scheduler) Tick() error {
+	leader, err := s.store.AcquireCronLeader(s.identity, s.leaderTTL)
+	if err != nil {
+		leader = false
+	}
+
+	s.mu.Lock()
+	changed := leader != s.leader
+	s.leader = leader
+	s.mu.Unlock()
+	if changed {
+		if leader {
+			log.Printf("Cron leadership acquired: identity=%s, jobs=%d", s.identity, len(s.entries))
+		} else {
+			log.Printf("Cron leadership lost: identity=%s", s.identity)
+		}
+	}
+	if err != nil || !leader {
+		return err
+	}
+
+	now := s.now()
+	for _, entry := range s.entries {
+		if err := s.enqueue(entry, now); err != nil {
+			log.Printf("Cron error: %v", err)
+		}
+	}
+	return nil
+}
+
+// enqueue pushes the ticks of entry that fell due since its last recorded tick
+func (s *Scheduler) enqueue(entry Entry, now time.Time) error {
+	last, err := s.store.CronLastTick(entry.Name)
+	if err != nil {
+		return err
+	}
+
+	// A new job starts from now rather than replaying its whole history
+	if last == 0 {
+		_, err := s.store.EnqueueCronTicks(entry.Name, 0, now.Unix(), nil)
+		return err
+	}
+
+	due := s.dueTicks(entry, last, now)
+	if len(due) == 0 {
+		return nil
+	}
+
+	ticks := s.selectTicks(entry.CatchUp, due, now)
+	jobs := make([]*job.SidekiqJob, len(ticks))
+	for i := range ticks {
+		jobs[i] = &job.SidekiqJob{
+			Class: entry.Class,
+			Queue: entry.Queue,
+			Args:  entry.Args,
+		}
+	}
+
+	latest := due[len(due)-1]
+	enqueued, err := s.store.EnqueueCronTicks(entry.Name, last, latest.Unix(), jobs)
+	if err != nil || !enqueued {
+		return err
+	}
+	if skipped := len(due) - len(ticks); skipped > 0 {
+		log.Printf("Cron ticks missed: Name=%s, Skipped=%d, Policy=%s", entry.Name, skipped, entry.CatchUp)
+	}
+	for i, tick := range ticks {
+		log.Printf("Cron job enqueued: Name=%s, Class=%s, JID=%s, Tick=%s", entry.Name, entry.Class, jobs[i].JID, tick.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// dueTicks returns the ticks after last and up to now, keeping the most recent
+// maxCatchUp plus the latest so callers always know how far to advance
+func (s *Scheduler) dueTicks(entry Entry, last int64, now time.Time) []time.Time {
+	var due []time.Time
+	limit := s.maxCatchUp
+	if limit < 1 {
+		limit = 1
+	}
+
+	for tick := entry.Schedule.Next(time.Unix(last, 0).In(entry.Location)); !tick.IsZero() && !tick.After(now); tick = entry.Schedule.Next(tick) {
+		due = append(due, tick)
+		if len(due) > limit {
+			due = due[1:]
+		}
+	}
+	return due
+}
+
+// selectTicks applies a catch-up policy to the due ticks
+func (s *Scheduler) selectTicks(policy string, due []time.Time, now time.Time) []time.Time {
+	switch policy {
+	case CatchUpAll:
+		return due
+	case CatchUpLast:
+		return due[len(due)-1:]
+	default:
+		var onTime []time.Time
+		for _, tick := range due {
+			if now.Sub(tick) <= s.grace {
+				onTime = append(onTime, tick)
+			}
+		}
+		return onTime
+	}
+}
+
+// jsonArgs converts YAML-decoded maps, which have interface{} keys, into maps
+// that can be encoded as JSON
+func jsonArgs(args []interface{}) []interface{} {
+	if args == nil {
+		return []interface{}{}
+	}
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		converted[i] = jsonValue(arg)
+	}
+	return converted
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			converted[fmt.Sprint(k)] = jsonValue(item)
+		}
+		return converted
+	case []interface{}:
+		return jsonArgs(value)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,161 @@
+package cron
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gokiq/internal/job"
+)
+
+// fakeStore is an in-memory Store shared by several schedulers
+type fakeStore struct {
+	mu     sync.Mutex
+	leader string
+	last   map[string]int64
+	jobs   []*job.SidekiqJob
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{last: make(map[string]int64)}
+}
+
+func (f *fakeStore) AcquireCronLeader(identity string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.leader == "" {
+		f.leader = identity
+	}
+	return f.leader == identity, nil
+}
+
+func (f *fakeStore) ReleaseCronLeader(identity string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.leader == identity {
+		f.leader = ""
+	}
+	return nil
+}
+
+func (f *fakeStore) CronLastTick(name string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.last[name], nil
+}
+
+func (f *fakeStore) EnqueueCronTicks(name string, previous, tick int64, jobs []*job.SidekiqJob) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.last[name] != previous {
+		return false, nil
+	}
+	f.last[name] = tick
+	f.jobs = append(f.jobs, jobs...)
+	return true, nil
+}
+
+func newTestScheduler(t *testing.T, store Store, identity, catchUp string, now *time.Time) *Scheduler {
+	t.Helper()
+	entry, err := NewEntry("report", "ReportJob", "", []interface{}{map[interface{}]interface{}{"full": true}}, "*/10 * * * *", "Europe/Berlin", catchUp)
+	if err != nil {
+		t.Fatalf("NewEntry failed: %v", err)
+	}
+	scheduler := NewScheduler(store, identity, []Entry{entry}, 30*time.Second, time.Minute, 3)
+	scheduler.now = func() time.Time { return *now }
+	return scheduler
+}
+
+func TestScheduler_EnqueuesEachTickOnce(t *testing.T) {
+	store := newFakeStore()
+	now := time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC)
+	leader := newTestScheduler(t, store, "host:1", CatchUpSkip, &now)
+	follower := newTestScheduler(t, store, "host:2", CatchUpSkip, &now)
+
+	// The first check records a starting point without enqueuing
+	for _, s := range []*Scheduler{leader, follower} {
+		if err := s.Tick(); err != nil {
+			t.Fatalf("Tick failed: %v", err)
+		}
+	}
+	if !leader.IsLeader() || follower.IsLeader() {
+		t.Fatalf("Expected host:1 to lead, got %v/%v", leader.IsLeader(), follower.IsLeader())
+	}
+	if len(store.jobs) != 0 {
+		t.Fatalf("Expected no jobs before the first tick, got %d", len(store.jobs))
+	}
+
+	now = time.Date(2024, 1, 1, 10, 10, 1, 0, time.UTC)
+	leader.Tick()
+	leader.Tick()
+	follower.Tick()
+	if len(store.jobs) != 1 {
+		t.Fatalf("Expected one job for the 10:10 tick, got %d", len(store.jobs))
+	}
+	if got := store.jobs[0]; got.Class != "ReportJob" || got.Queue != "default" {
+		t.Errorf("Unexpected job %+v", got)
+	}
+	if args, ok := store.jobs[0].Args[0].(map[string]interface{}); !ok || args["full"] != true {
+		t.Errorf("Expected YAML map args to be JSON-compatible, got %#v", store.jobs[0].Args)
+	}
+
+	// A new leader whose clock lags does not enqueue the tick again
+	leader.store.ReleaseCronLeader("host:1")
+	now = time.Date(2024, 1, 1, 10, 9, 58, 0, time.UTC)
+	follower.Tick()
+	if !follower.IsLeader() || len(store.jobs) != 1 {
+		t.Errorf("Expected the new leader to skip recorded ticks, got leader=%v jobs=%d", follower.IsLeader(), len(store.jobs))
+	}
+}
+
+func TestScheduler_CatchUp(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   int
+	}{
+		{CatchUpSkip, 1},
+		{CatchUpLast, 1},
+		{CatchUpAll, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			store := newFakeStore()
+			// Down since 09:05, back at 10:00:30: six ticks were missed
+			store.last["report"] = time.Date(2024, 1, 1, 9, 5, 0, 0, time.UTC).Unix()
+			now := time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC)
+			scheduler := newTestScheduler(t, store, "host:1", tt.policy, &now)
+
+			if err := scheduler.Tick(); err != nil {
+				t.Fatalf("Tick failed: %v", err)
+			}
+			if len(store.jobs) != tt.want {
+				t.Errorf("Expected %d jobs, got %d", tt.want, len(store.jobs))
+			}
+			if want := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC).Unix(); store.last["report"] != want {
+				t.Errorf("Expected last tick %d, got %d", want, store.last["report"])
+			}
+
+			// The 10:00 tick was on time; without it skip enqueues nothing
+			store.jobs = nil
+			store.last["report"] = time.Date(2024, 1, 1, 9, 5, 0, 0, time.UTC).Unix()
+			now = time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC)
+			scheduler.Tick()
+			if tt.policy == CatchUpSkip && len(store.jobs) != 0 {
+				t.Errorf("Expected skip to drop missed ticks, got %d jobs", len(store.jobs))
+			}
+		})
+	}
+}
+
+func TestNewEntry_Invalid(t *testing.T) {
+	if _, err := NewEntry("report", "ReportJob", "", nil, "every minute", "", CatchUpSkip); err == nil {
+		t.Error("Expected error for an invalid schedule")
+	}
+	if _, err := NewEntry("report", "ReportJob", "", nil, "@daily", "Mars/Olympus", CatchUpSkip); err == nil {
+		t.Error("Expected error for an unknown time zone")
+	}
+	if _, err := NewEntry("report", "ReportJob", "", nil, "@daily", "", "sometimes"); err == nil {
+		t.Error("Expected error for an unknown catch-up policy")
+	}
+}
@@ -0,0 +1,105 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gokiq/internal/config"
+	"gokiq/internal/job"
+)
+
+// ErrUnknownKey is returned when a job was encrypted with a key that is not
+// in the key ring
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// Keyring encrypts job args with its current key and decrypts them with any
+// of its keys
+type Keyring struct {
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewKeyring creates a key ring from the keys in cfg
+func NewKeyring(cfg config.EncryptionConfig) (*Keyring, error) {
+	k := &Keyring{
+		current: cfg.Current,
+		aeads:   make(map[string]cipher.AEAD, len(cfg.Keys)),
+	}
+	for id, encoded := range cfg.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode encryption key %s: %w", id, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %s: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %s: %w", id, err)
+		}
+		k.aeads[id] = aead
+	}
+	if _, ok := k.aeads[k.current]; !ok {
+		return nil, fmt.Errorf("current encryption key %q is not in the key ring", k.current)
+	}
+	return k, nil
+}
+
+// Encrypt replaces the args of jobData with their encryption under the
+// current key. The JID is authenticated with them, so the ciphertext cannot be
+// moved to another job; it must already be set. Encrypted jobs are left as is.
+func (k *Keyring) Encrypt(jobData *job.SidekiqJob) error {
+	if jobData.EncryptionKey != "" {
+		return nil
+	}
+
+	plaintext, err := json.Marshal(jobData.Args)
+	if err != nil {
+		return fmt.Errorf("failed to marshal args: %w", err)
+	}
+
+	aead := k.aeads[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(jobData.JID))
+
+	jobData.Args = []interface{}{base64.StdEncoding.EncodeToString(sealed)}
+	jobData.EncryptionKey = k.current
+	return nil
+}
+
+// Decrypt returns the plaintext args of an encrypted job
+func (k *Keyring) Decrypt(jobData *job.SidekiqJob) ([]interface{}, error) {
+	aead, ok := k.aeads[jobData.EncryptionKey]
+	if !ok {
+		return nil, fmt.Errorf("failed to decrypt args of %s: %w %q", jobData.JID, ErrUnknownKey, jobData.EncryptionKey)
+	}
+
+	var encoded string
+	if len(jobData.Args) == 1 {
+		encoded, _ = jobData.Args[0].(string)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt args of %s: malformed ciphertext", jobData.JID)
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(jobData.JID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt args of %s: %w", jobData.JID, err)
+	}
+
+	var args []interface{}
+	if err := json.Unmarshal(plaintext, &args); err != nil {
+		return nil, fmt.Errorf("failed to decode args of %s: %w", jobData.JID, err)
+	}
+	return args, nil
+}
@@ -0,0 +1,106 @@
+package encryption
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gokiq/internal/config"
+	"gokiq/internal/job"
+)
+
+const (
+	oldKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	newKey = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func newTestKeyring(t *testing.T, current string, keys map[string]string) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyring(config.EncryptionConfig{Enabled: true, Current: current, Keys: keys})
+	if err != nil {
+		t.Fatalf("NewKeyring failed: %v", err)
+	}
+	return keyring
+}
+
+func TestKeyring_RoundTrip(t *testing.T) {
+	keyring := newTestKeyring(t, "k1", map[string]string{"k1": oldKey})
+	jobData := &job.SidekiqJob{JID: "jid-1", Args: []interface{}{"alice@example.com", 42.0}}
+
+	if err := keyring.Encrypt(jobData); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if jobData.EncryptionKey != "k1" || len(jobData.Args) != 1 {
+		t.Fatalf("Expected a single ciphertext arg under k1, got %+v", jobData)
+	}
+	if strings.Contains(jobData.Args[0].(string), "alice") {
+		t.Fatalf("Expected ciphertext, got %v", jobData.Args[0])
+	}
+
+	// Already encrypted jobs, e.g. retries, are not encrypted twice
+	ciphertext := jobData.Args[0]
+	if err := keyring.Encrypt(jobData); err != nil || jobData.Args[0] != ciphertext {
+		t.Fatalf("Expected an encrypted job to be left alone, got %+v, %v", jobData, err)
+	}
+
+	args, err := keyring.Decrypt(jobData)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if len(args) != 2 || args[0] != "alice@example.com" || args[1] != 42.0 {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	before := newTestKeyring(t, "k1", map[string]string{"k1": oldKey})
+	jobData := &job.SidekiqJob{JID: "jid-1", Args: []interface{}{"secret"}}
+	if err := before.Encrypt(jobData); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	after := newTestKeyring(t, "k2", map[string]string{"k1": oldKey, "k2": newKey})
+	if args, err := after.Decrypt(jobData); err != nil || args[0] != "secret" {
+		t.Fatalf("Expected the old key to still decrypt, got %v, %v", args, err)
+	}
+
+	fresh := &job.SidekiqJob{JID: "jid-2", Args: []interface{}{"secret"}}
+	if err := after.Encrypt(fresh); err != nil || fresh.EncryptionKey != "k2" {
+		t.Fatalf("Expected new jobs to use k2, got %+v, %v", fresh, err)
+	}
+
+	retired := newTestKeyring(t, "k2", map[string]string{"k2": newKey})
+	if _, err := retired.Decrypt(jobData); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey once k1 is removed, got %v", err)
+	}
+}
+
+func TestKeyring_RejectsTampering(t *testing.T) {
+	keyring := newTestKeyring(t, "k1", map[string]string{"k1": oldKey})
+	jobData := &job.SidekiqJob{JID: "jid-1", Args: []interface{}{"secret"}}
+	if err := keyring.Encrypt(jobData); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	// The ciphertext is bound to its JID
+	moved := *jobData
+	moved.JID = "jid-2"
+	if _, err := keyring.Decrypt(&moved); err == nil {
+		t.Error("Expected ciphertext moved to another job to be rejected")
+	}
+
+	malformed := *jobData
+	malformed.Args = []interface{}{"not base64!"}
+	if _, err := keyring.Decrypt(&malformed); err == nil {
+		t.Error("Expected malformed ciphertext to be rejected")
+	}
+}
+
+func TestNewKeyring_Invalid(t *testing.T) {
+	if _, err := NewKeyring(config.EncryptionConfig{Current: "k2", Keys: map[string]string{"k1": oldKey}}); err == nil {
+		t.Error("Expected an error for a missing current key")
+	}
+	if _, err := NewKeyring(config.EncryptionConfig{Current: "k1", Keys: map[string]string{"k1": "c2hvcnQ="}}); err == nil {
+		t.Error("Expected an error for a short key")
+	}
+}
@@ -0,0 +1,47 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+
+	"gokiq/internal/concurrency"
+	"gokiq/internal/job"
+)
+
+// decryptionErrorClass is recorded when a job's args cannot be decrypted
+const decryptionErrorClass = "Gokiq::DecryptionError"
+
+// Middleware is server middleware that decrypts args right before a job runs.
+// It decrypts a copy, so retries and the dead set keep the ciphertext, and
+// redacts the error message of a failure, which may quote the args and is
+// logged and stored in the payload. A job that cannot be decrypted goes to the
+// dead set, where it can be retried once its key is back in the key ring.
+func Middleware(keyring *Keyring) concurrency.Middleware {
+	return func(ctx context.Context, jobData *job.SidekiqJob, next concurrency.Next) (*job.JobResult, error) {
+		if jobData.EncryptionKey == "" {
+			return next(ctx, jobData)
+		}
+
+		args, err := keyring.Decrypt(jobData)
+		if err != nil {
+			retryable := false
+			return &job.JobResult{
+				Status:       "failure",
+				ErrorClass:   decryptionErrorClass,
+				ErrorMessage: err.Error(),
+				Retryable:    &retryable,
+			}, nil
+		}
+
+		decrypted := *jobData
+		decrypted.Args = args
+		decrypted.EncryptionKey = ""
+		result, err := next(ctx, &decrypted)
+		if err == nil && result != nil && result.Status == "failure" && result.ErrorMessage != "" {
+			redacted := *result
+			redacted.ErrorMessage = fmt.Sprintf("[%d bytes redacted]", len(result.ErrorMessage))
+			result = &redacted
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,69 @@
+package encryption
+
+import (
+	"context"
+	"testing"
+
+	"gokiq/internal/job"
+)
+
+func TestMiddleware_DecryptsCopy(t *testing.T) {
+	keyring := newTestKeyring(t, "k1", map[string]string{"k1": oldKey})
+	jobData := &job.SidekiqJob{JID: "jid-1", Class: "NotifyJob", Args: []interface{}{"alice@example.com"}}
+	if err := keyring.Encrypt(jobData); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	ciphertext := jobData.Args[0]
+
+	var executed *job.SidekiqJob
+	next := func(ctx context.Context, j *job.SidekiqJob) (*job.JobResult, error) {
+		executed = j
+		return &job.JobResult{Status: "failure"}, nil
+	}
+
+	if _, err := Middleware(keyring)(context.Background(), jobData, next); err != nil {
+		t.Fatalf("Middleware failed: %v", err)
+	}
+	if executed.EncryptionKey != "" || len(executed.Args) != 1 || executed.Args[0] != "alice@example.com" {
+		t.Errorf("Expected plaintext args for execution, got %+v", executed)
+	}
+	if jobData.EncryptionKey != "k1" || jobData.Args[0] != ciphertext {
+		t.Errorf("Expected the job to stay encrypted for retries, got %+v", jobData)
+	}
+}
+
+func TestMiddleware_UndecryptableJob(t *testing.T) {
+	keyring := newTestKeyring(t, "k1", map[string]string{"k1": oldKey})
+	jobData := &job.SidekiqJob{JID: "jid-1", Class: "NotifyJob", Args: []interface{}{"AAAA"}, EncryptionKey: "k0"}
+	next := func(ctx context.Context, j *job.SidekiqJob) (*job.JobResult, error) {
+		t.Fatal("Undecryptable job must not run")
+		return nil, nil
+	}
+
+	result, err := Middleware(keyring)(context.Background(), jobData, next)
+	if err != nil {
+		t.Fatalf("Middleware failed: %v", err)
+	}
+	if result.ErrorClass != decryptionErrorClass || result.Retryable == nil || *result.Retryable {
+		t.Errorf("Expected a non-retryable decryption failure, got %+v", result)
+	}
+}
+
+func TestMiddleware_RedactsFailureMessage(t *testing.T) {
+	keyring := newTestKeyring(t, "k1", map[string]string{"k1": oldKey})
+	jobData := &job.SidekiqJob{JID: "jid-1", Class: "NotifyJob", Args: []interface{}{"alice@example.com"}}
+	if err := keyring.Encrypt(jobData); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	next := func(ctx context.Context, j *job.SidekiqJob) (*job.JobResult, error) {
+		return &job.JobResult{Status: "failure", ErrorClass: "ArgumentError", ErrorMessage: "invalid address alice@example.com"}, nil
+	}
+
+	result, err := Middleware(keyring)(context.Background(), jobData, next)
+	if err != nil {
+		t.Fatalf("Middleware failed: %v", err)
+	}
+	if result.ErrorMessage != "[33 bytes redacted]" || result.ErrorClass != "ArgumentError" {
+		t.Errorf("Expected the error message to be redacted, got %+v", result)
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"errors"
+	"time"
+)
+
+// Failure wraps a handler error with instructions for the processor, matching
+// the retryable, retry_in and discard fields of a sidecar failure
+type Failure struct {
+	Err       error
+	Retryable bool
+	RetryIn   time.Duration
+	Discard   bool
+}
+
+func (f *Failure) Error() string { return f.Err.Error() }
+
+func (f *Failure) Unwrap() error { return f.Err }
+
+// NoRetry fails the job and sends it straight to the dead set
+func NoRetry(err error) error {
+	return &Failure{Err: err}
+}
+
+// Discard fails the job and drops it without retrying or keeping it as dead
+func Discard(err error) error {
+	return &Failure{Err: err, Discard: true}
+}
+
+// RetryIn fails the job and retries it after delay instead of the usual backoff
+func RetryIn(err error, delay time.Duration) error {
+	return &Failure{Err: err, Retryable: true, RetryIn: delay}
+}
+
+// asFailure returns the Failure wrapped in err, if any
+func asFailure(err error) (*Failure, bool) {
+	var failure *Failure
+	ok := errors.As(err, &failure)
+	return failure, ok
+}
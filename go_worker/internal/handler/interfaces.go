@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"context"
+
+	"gokiq/internal/job"
+)
+
+// Func executes a job in-process. Returning an error fails the job, which is
+// then retried like any sidecar failure; wrap it with NoRetry, Discard or
+// RetryIn to change that.
+type Func func(ctx context.Context, job *job.SidekiqJob) error
+
+// Fallback executes jobs that have no registered handler
+type Fallback interface {
+	ExecuteJob(job *job.SidekiqJob) (*job.JobResult, error)
+}
+
+// ClassedError is an error that names its class in the job's error_class,
+// like a Ruby exception class. Errors without one are recorded under the
+// job's class.
+type ClassedError interface {
+	error
+	ErrorClass() string
+}
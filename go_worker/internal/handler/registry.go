@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry maps Sidekiq class names to Go handlers
+type Registry struct {
+	handlers map[string]Func
+	mu       sync.RWMutex
+}
+
+// NewRegistry creates an empty handler registry
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]Func)}
+}
+
+// Register handles jobs of class in-process
+func (r *Registry) Register(class string, fn Func) error {
+	if class == "" {
+		return fmt.Errorf("handler class must not be empty")
+	}
+	if fn == nil {
+		return fmt.Errorf("handler for %s must not be nil", class)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.handlers[class]; exists {
+		return fmt.Errorf("handler for %s is already registered", class)
+	}
+	r.handlers[class] = fn
+	return nil
+}
+
+// Lookup returns the handler registered for class
+func (r *Registry) Lookup(class string) (Func, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.handlers[class]
+	return fn, ok
+}
+
+// Classes returns the registered class names, sorted
+func (r *Registry) Classes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	classes := make([]string, 0, len(r.handlers))
+	for class := range r.handlers {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"gokiq/internal/job"
+)
+
+// panicErrorClass is recorded when a Go handler panics
+const panicErrorClass = "Gokiq::HandlerPanic"
+
+// Router executes jobs with a registered Go handler in-process and sends
+// everything else to the fallback, normally the sidecar client. It sits at
+// the end of the processor's middleware chain, so both kinds of job share
+// retries, metrics and middleware.
+type Router struct {
+	registry *Registry
+	fallback Fallback
+}
+
+// NewRouter creates a router over registry that falls back to fallback
+func NewRouter(registry *Registry, fallback Fallback) *Router {
+	return &Router{
+		registry: registry,
+		fallback: fallback,
+	}
+}
+
+// ExecuteJob executes a job without a middleware context
+func (r *Router) ExecuteJob(job *job.SidekiqJob) (*job.JobResult, error) {
+	return r.ExecuteJobContext(context.Background(), job)
+}
+
+// ExecuteJobContext runs the handler registered for the job's class, or
+// passes the job to the fallback
+func (r *Router) ExecuteJobContext(ctx context.Context, jobData *job.SidekiqJob) (*job.JobResult, error) {
+	fn, ok := r.registry.Lookup(jobData.Class)
+	if !ok {
+		return r.fallback.ExecuteJob(jobData)
+	}
+
+	start := time.Now()
+	result := run(ctx, fn, jobData)
+	result.ExecutionTime = time.Since(start).Seconds()
+	return result, nil
+}
+
+// run calls fn, converting its error or panic into a failure result
+func run(ctx context.Context, fn Func, jobData *job.SidekiqJob) (result *job.JobResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = &job.JobResult{
+				Status:       "failure",
+				ErrorClass:   panicErrorClass,
+				ErrorMessage: fmt.Sprint(r),
+				Backtrace:    strings.Split(strings.TrimSpace(string(debug.Stack())), "\n"),
+			}
+		}
+	}()
+
+	if err := fn(ctx, jobData); err != nil {
+		return failureResult(jobData, err)
+	}
+	return &job.JobResult{Status: "success"}
+}
+
+// failureResult describes err the way the sidecar describes a Ruby exception
+func failureResult(jobData *job.SidekiqJob, err error) *job.JobResult {
+	result := &job.JobResult{
+		Status:       "failure",
+		ErrorClass:   errorClass(jobData, err),
+		ErrorMessage: err.Error(),
+	}
+
+	if failure, ok := asFailure(err); ok {
+		retryable := failure.Retryable
+		result.Retryable = &retryable
+		result.RetryIn = failure.RetryIn.Seconds()
+		result.Discard = failure.Discard
+	}
+	return result
+}
+
+// errorClass returns the class err names for itself, or the job's class
+func errorClass(jobData *job.SidekiqJob, err error) string {
+	var classed ClassedError
+	if errors.As(err, &classed) {
+		return classed.ErrorClass()
+	}
+	return jobData.Class
+}
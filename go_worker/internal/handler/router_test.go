@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gokiq/internal/job"
+)
+
+// fakeFallback records the jobs passed to it
+type fakeFallback struct {
+	jobs []*job.SidekiqJob
+}
+
+func (f *fakeFallback) ExecuteJob(jobData *job.SidekiqJob) (*job.JobResult, error) {
+	f.jobs = append(f.jobs, jobData)
+	return &job.JobResult{Status: "success", Result: "sidecar"}, nil
+}
+
+type ctxKey struct{}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+	noop := func(ctx context.Context, job *job.SidekiqJob) error { return nil }
+
+	if err := registry.Register("B", noop); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := registry.Register("A", noop); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := registry.Register("A", noop); err == nil {
+		t.Error("Expected error registering a class twice")
+	}
+	if err := registry.Register("", noop); err == nil {
+		t.Error("Expected error registering an empty class")
+	}
+	if err := registry.Register("C", nil); err == nil {
+		t.Error("Expected error registering a nil handler")
+	}
+
+	if classes := registry.Classes(); len(classes) != 2 || classes[0] != "A" || classes[1] != "B" {
+		t.Errorf("Expected classes [A B], got %v", classes)
+	}
+}
+
+func TestRouter_Dispatch(t *testing.T) {
+	registry := NewRegistry()
+	fallback := &fakeFallback{}
+	router := NewRouter(registry, fallback)
+
+	var got interface{}
+	registry.Register("GoJob", func(ctx context.Context, job *job.SidekiqJob) error {
+		got = ctx.Value(ctxKey{})
+		return nil
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "tenant-42")
+	result, err := router.ExecuteJobContext(ctx, &job.SidekiqJob{JID: "go", Class: "GoJob"})
+	if err != nil || result.Status != "success" {
+		t.Fatalf("Expected success, got %+v, %v", result, err)
+	}
+	if got != "tenant-42" {
+		t.Errorf("Expected the middleware context to reach the handler, got %v", got)
+	}
+	if len(fallback.jobs) != 0 {
+		t.Error("Expected registered class not to reach the sidecar")
+	}
+
+	result, err = router.ExecuteJob(&job.SidekiqJob{JID: "rb", Class: "RailsJob"})
+	if err != nil || result.Result != "sidecar" {
+		t.Fatalf("Expected sidecar result, got %+v, %v", result, err)
+	}
+	if len(fallback.jobs) != 1 || fallback.jobs[0].JID != "rb" {
+		t.Errorf("Expected unregistered class to reach the sidecar, got %v", fallback.jobs)
+	}
+}
+
+type paymentError struct{}
+
+func (paymentError) Error() string      { return "card declined" }
+func (paymentError) ErrorClass() string { return "Payments::Declined" }
+
+func TestRouter_Failures(t *testing.T) {
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name      string
+		fn        Func
+		class     string
+		retryable *bool
+		retryIn   float64
+		discard   bool
+	}{
+		{
+			name:  "plain error",
+			fn:    func(ctx context.Context, job *job.SidekiqJob) error { return boom },
+			class: "GoJob",
+		},
+		{
+			name:      "no retry",
+			fn:        func(ctx context.Context, job *job.SidekiqJob) error { return NoRetry(boom) },
+			class:     "GoJob",
+			retryable: new(bool),
+		},
+		{
+			name:      "retry in",
+			fn:        func(ctx context.Context, job *job.SidekiqJob) error { return RetryIn(boom, 90*time.Second) },
+			class:     "GoJob",
+			retryable: boolPtr(true),
+			retryIn:   90,
+		},
+		{
+			name:      "discard",
+			fn:        func(ctx context.Context, job *job.SidekiqJob) error { return Discard(boom) },
+			class:     "GoJob",
+			retryable: new(bool),
+			discard:   true,
+		},
+		{
+			name:  "classed error",
+			fn:    func(ctx context.Context, job *job.SidekiqJob) error { return fmt.Errorf("charge: %w", paymentError{}) },
+			class: "Payments::Declined",
+		},
+		{
+			name:      "classed error without retry",
+			fn:        func(ctx context.Context, job *job.SidekiqJob) error { return NoRetry(paymentError{}) },
+			class:     "Payments::Declined",
+			retryable: new(bool),
+		},
+		{
+			name:  "panic",
+			fn:    func(ctx context.Context, job *job.SidekiqJob) error { panic("kaboom") },
+			class: panicErrorClass,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.Register("GoJob", tt.fn)
+			router := NewRouter(registry, &fakeFallback{})
+
+			result, err := router.ExecuteJob(&job.SidekiqJob{JID: "jid", Class: "GoJob"})
+			if err != nil {
+				t.Fatalf("Expected handler failures to be results, got error %v", err)
+			}
+			if result.Status != "failure" || result.ErrorClass != tt.class {
+				t.Errorf("Expected failure with class %s, got %+v", tt.class, result)
+			}
+			if (result.Retryable == nil) != (tt.retryable == nil) ||
+				(result.Retryable != nil && *result.Retryable != *tt.retryable) {
+				t.Errorf("Expected retryable %v, got %v", tt.retryable, result.Retryable)
+			}
+			if result.RetryIn != tt.retryIn || result.Discard != tt.discard {
+				t.Errorf("Expected retry_in %v and discard %v, got %+v", tt.retryIn, tt.discard, result)
+			}
+			if tt.name == "panic" && len(result.Backtrace) == 0 {
+				t.Error("Expected a backtrace for a panic")
+			}
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
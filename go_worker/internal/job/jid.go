@@ -0,0 +1,15 @@
+package job
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewJID generates a random Sidekiq-style job ID (24 hex characters)
+func NewJID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
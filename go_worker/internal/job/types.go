@@ -12,6 +12,37 @@ type SidekiqJob struct {
 	FailedAt   float64       `json:"failed_at,omitempty"`
 	ErrorMsg   string        `json:"error_message,omitempty"`
 	ErrorClass string        `json:"error_class,omitempty"`
+
+	// ErrorBacktrace is the backtrace of the last failure, as Sidekiq stores it
+	ErrorBacktrace []string `json:"error_backtrace,omitempty"`
+
+	// Tags are shown alongside the job in the Sidekiq Web UI
+	Tags []string `json:"tags,omitempty"`
+
+	// BID is the ID of the batch the job belongs to, as in Sidekiq Pro
+	BID string `json:"bid,omitempty"`
+
+	// WorkflowID and WorkflowStep identify the workflow step the job runs
+	WorkflowID   string `json:"wid,omitempty"`
+	WorkflowStep string `json:"wf_step,omitempty"`
+
+	// InterruptedCount is incremented each time the job is pushed back onto its
+	// queue because the worker shut down mid-execution, so Rails code can detect
+	// a possible re-execution
+	InterruptedCount int `json:"interrupted_count,omitempty"`
+
+	// ArgsRef is the blob store key holding the args when they were too large
+	// to keep in Redis; Args is then empty
+	ArgsRef string `json:"args_ref,omitempty"`
+
+	// EncryptionKey is the ID of the key the args were encrypted with. Args
+	// then holds a single base64 string: the AES-GCM nonce and ciphertext.
+	EncryptionKey string `json:"encryption_key,omitempty"`
+
+	// ExpiresAt is the Unix time after which the job is skipped rather than
+	// run; ExpiresIn sets it in seconds after created_at instead
+	ExpiresAt float64 `json:"expires_at,omitempty"`
+	ExpiresIn float64 `json:"expires_in,omitempty"`
 }
 
 // JobResult represents the response from the Rails sidecar after job execution
@@ -20,4 +51,20 @@ type JobResult struct {
 	Result        string  `json:"result"`
 	ExecutionTime float64 `json:"execution_time"`
 	ErrorMessage  string  `json:"error_message,omitempty"`
+
+	// ErrorClass and Backtrace describe the Ruby exception of a failed job
+	ErrorClass string   `json:"error_class,omitempty"`
+	Backtrace  []string `json:"backtrace,omitempty"`
+
+	// Retryable set to false sends a failed job straight to the dead set
+	Retryable *bool `json:"retryable,omitempty"`
+
+	// RetryIn overrides the retry delay, in seconds
+	RetryIn float64 `json:"retry_in,omitempty"`
+
+	// Discard drops a failed job without retrying it or keeping it as dead
+	Discard bool `json:"discard,omitempty"`
+
+	// Tags are added to the job payload when it is retried or killed
+	Tags []string `json:"tags,omitempty"`
 }
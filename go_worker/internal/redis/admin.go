@@ -0,0 +1,303 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"gokiq/internal/job"
+)
+
+// Sidekiq sorted set names
+const (
+	ScheduleSet = "schedule"
+	RetrySet    = "retry"
+	DeadSet     = "dead"
+
+	// ExpiredSet keeps jobs skipped because they expired, when enabled
+	ExpiredSet = "expired"
+)
+
+// ErrJobNotFound is returned when a JID cannot be found in a sorted set
+var ErrJobNotFound = errors.New("job not found")
+
+// QueueInfo describes a Sidekiq queue
+type QueueInfo struct {
+	Name    string  `json:"name"`
+	Size    int64   `json:"size"`
+	Latency float64 `json:"latency"`
+}
+
+// SetEntry is a job stored in one of the schedule, retry or dead sorted sets
+type SetEntry struct {
+	Score float64         `json:"score"`
+	Job   *job.SidekiqJob `json:"job"`
+
+	member string
+}
+
+// Queues returns every known queue with its size and latency in seconds
+func (c *Client) Queues() ([]QueueInfo, error) {
+	names, err := c.client.SMembers(c.ctx, c.key("queues")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+	sort.Strings(names)
+
+	pipe := c.client.Pipeline()
+	sizes := make([]*redis.IntCmd, len(names))
+	oldest := make([]*redis.StringSliceCmd, len(names))
+	for i, name := range names {
+		queueName := c.queueKey(name)
+		sizes[i] = pipe.LLen(c.ctx, queueName)
+		oldest[i] = pipe.LRange(c.ctx, queueName, -1, -1)
+	}
+	if len(names) > 0 {
+		if _, err := pipe.Exec(c.ctx); err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to read queue sizes: %w", err)
+		}
+	}
+
+	now := float64(time.Now().UnixNano()) / 1e9
+	queues := make([]QueueInfo, len(names))
+	for i, name := range names {
+		queues[i] = QueueInfo{Name: name, Size: sizes[i].Val()}
+
+		// Latency is the age of the oldest job still waiting in the queue
+		if entries := oldest[i].Val(); len(entries) > 0 {
+			var oldestJob job.SidekiqJob
+			if err := json.Unmarshal([]byte(entries[0]), &oldestJob); err == nil && oldestJob.EnqueuedAt > 0 {
+				queues[i].Latency = now - oldestJob.EnqueuedAt
+			}
+		}
+	}
+
+	return queues, nil
+}
+
+// ClearQueue deletes all jobs in a queue and removes it from the queue list
+func (c *Client) ClearQueue(queueName string) error {
+	pipe := c.client.TxPipeline()
+	pipe.Del(c.ctx, c.queueKey(queueName))
+	pipe.SRem(c.ctx, c.key("queues"), queueName)
+
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return fmt.Errorf("failed to clear queue %s: %w", queueName, err)
+	}
+
+	return nil
+}
+
+// SetSize returns the number of entries in a sorted set
+func (c *Client) SetSize(set string) (int64, error) {
+	return c.client.ZCard(c.ctx, c.key(set)).Result()
+}
+
+// ListSet returns a page of entries from a sorted set ordered by score
+func (c *Client) ListSet(set string, offset, limit int64) ([]SetEntry, error) {
+	if limit <= 0 {
+		return []SetEntry{}, nil
+	}
+
+	result, err := c.client.ZRangeWithScores(c.ctx, c.key(set), offset, offset+limit-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s set: %w", set, err)
+	}
+
+	entries := make([]SetEntry, 0, len(result))
+	for _, z := range result {
+		entry, err := parseSetEntry(z)
+		if err != nil {
+			// Skip malformed jobs but continue listing others
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, nil
+}
+
+// FindInSet looks up a job by JID in a sorted set
+func (c *Client) FindInSet(set, jid string) (*SetEntry, error) {
+	var cursor uint64
+	for {
+		keys, next, err := c.client.ZScan(c.ctx, c.key(set), cursor, fmt.Sprintf("*%s*", jid), 100).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s set: %w", set, err)
+		}
+
+		// ZSCAN returns member and score pairs
+		for i := 0; i+1 < len(keys); i += 2 {
+			score, err := strconv.ParseFloat(keys[i+1], 64)
+			if err != nil {
+				continue
+			}
+			entry, err := parseSetEntry(redis.Z{Score: score, Member: keys[i]})
+			if err != nil {
+				continue
+			}
+			if entry.Job.JID == jid {
+				return entry, nil
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil, ErrJobNotFound
+		}
+	}
+}
+
+// DeleteFromSet removes a job from a sorted set
+func (c *Client) DeleteFromSet(set, jid string) error {
+	entry, err := c.FindInSet(set, jid)
+	if err != nil {
+		return err
+	}
+
+	if err := c.client.ZRem(c.ctx, c.key(set), entry.member).Err(); err != nil {
+		return fmt.Errorf("failed to delete job %s from %s set: %w", jid, set, err)
+	}
+
+	return nil
+}
+
+// RetryFromSet moves a job from a sorted set back onto its queue immediately.
+// A job another caller moved first is reported as not found.
+func (c *Client) RetryFromSet(set, jid string) error {
+	entry, err := c.FindInSet(set, jid)
+	if err != nil {
+		return err
+	}
+
+	entry.Job.EnqueuedAt = float64(time.Now().UnixNano()) / 1e9
+	moved, err := c.RequeueFromSet(set, entry)
+	if err != nil {
+		return err
+	}
+	if !moved {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+// killScript atomically removes a member from a sorted set and adds the
+// replacement payload to the dead set, trimming it to its 10000 newest jobs
+var killScript = redis.NewScript(`
+if redis.call('zrem', KEYS[1], ARGV[1]) == 1 then
+	redis.call('zadd', KEYS[2], ARGV[2], ARGV[3])
+	redis.call('zremrangebyrank', KEYS[2], 0, -10001)
+	return 1
+end
+return 0
+`)
+
+// KillFromSet moves a job from a sorted set into the dead set. A job another
+// caller moved first is reported as not found.
+func (c *Client) KillFromSet(set, jid string) error {
+	entry, err := c.FindInSet(set, jid)
+	if err != nil {
+		return err
+	}
+
+	now := float64(time.Now().Unix())
+	entry.Job.FailedAt = now
+	jobJSON, err := json.Marshal(entry.Job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	moved, err := killScript.Run(c.ctx, c.client, []string{c.key(set), c.key(DeadSet)},
+		entry.member, now, string(jobJSON)).Int()
+	if err != nil {
+		return fmt.Errorf("failed to kill job %s from %s set: %w", jid, set, err)
+	}
+	if moved == 0 {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+// ClearSet deletes every entry in a sorted set
+func (c *Client) ClearSet(set string) error {
+	if err := c.client.Del(c.ctx, c.key(set)).Err(); err != nil {
+		return fmt.Errorf("failed to clear %s set: %w", set, err)
+	}
+	return nil
+}
+
+// parseSetEntry decodes a sorted set member into a SetEntry
+func parseSetEntry(z redis.Z) (*SetEntry, error) {
+	member, ok := z.Member.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected member type %T", z.Member)
+	}
+
+	var sidekiqJob job.SidekiqJob
+	if err := json.Unmarshal([]byte(member), &sidekiqJob); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job JSON: %w", err)
+	}
+
+	return &SetEntry{Score: z.Score, Job: &sidekiqJob, member: member}, nil
+}
+
+// requeueScript atomically removes a member from a sorted set and pushes the
+// replacement payload onto its queue, so concurrent replays cannot duplicate it
+var requeueScript = redis.NewScript(`
+if redis.call('zrem', KEYS[1], ARGV[1]) == 1 then
+	redis.call('sadd', KEYS[3], ARGV[2])
+	redis.call('lpush', KEYS[2], ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// ScanSet returns entries with scores between min and max (inclusive, or
+// "-inf"/"+inf") without modifying the set
+func (c *Client) ScanSet(set, min, max string, offset, count int64) ([]SetEntry, error) {
+	result, err := c.client.ZRangeByScoreWithScores(c.ctx, c.key(set), &redis.ZRangeBy{
+		Min:    min,
+		Max:    max,
+		Offset: offset,
+		Count:  count,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s set: %w", set, err)
+	}
+
+	entries := make([]SetEntry, 0, len(result))
+	for _, z := range result {
+		entry, err := parseSetEntry(z)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, nil
+}
+
+// RequeueFromSet removes entry from a sorted set and pushes entry.Job onto its
+// queue. It returns false if the entry was no longer in the set.
+func (c *Client) RequeueFromSet(set string, entry *SetEntry) (bool, error) {
+	jobJSON, err := json.Marshal(entry.Job)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	queueName := c.queueKey(entry.Job.Queue)
+	moved, err := requeueScript.Run(c.ctx, c.client, []string{c.key(set), queueName, c.key("queues")},
+		entry.member, entry.Job.Queue, string(jobJSON)).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue job %s from %s set: %w", entry.Job.JID, set, err)
+	}
+
+	return moved == 1, nil
+}
@@ -0,0 +1,228 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+
+	"gokiq/internal/job"
+)
+
+func TestClient_Queues(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	enqueuedAt := float64(time.Now().Add(-10 * time.Second).Unix())
+	oldest, _ := json.Marshal(&job.SidekiqJob{JID: "old", Queue: "default", EnqueuedAt: enqueuedAt})
+
+	mock.ExpectSMembers("queues").SetVal([]string{"low", "default"})
+	mock.ExpectLLen("queue:default").SetVal(2)
+	mock.ExpectLRange("queue:default", -1, -1).SetVal([]string{string(oldest)})
+	mock.ExpectLLen("queue:low").SetVal(0)
+	mock.ExpectLRange("queue:low", -1, -1).SetVal([]string{})
+
+	queues, err := client.Queues()
+	if err != nil {
+		t.Fatalf("Queues failed: %v", err)
+	}
+
+	if len(queues) != 2 || queues[0].Name != "default" || queues[1].Name != "low" {
+		t.Fatalf("Expected sorted queues [default low], got %+v", queues)
+	}
+	if queues[0].Size != 2 {
+		t.Errorf("Expected default size 2, got %d", queues[0].Size)
+	}
+	if queues[0].Latency < 9 {
+		t.Errorf("Expected default latency of at least 9s, got %f", queues[0].Latency)
+	}
+	if queues[1].Latency != 0 {
+		t.Errorf("Expected empty queue latency 0, got %f", queues[1].Latency)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_ListSet(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	jobJSON, _ := json.Marshal(&job.SidekiqJob{JID: "jid-1", Class: "TestJob"})
+	mock.ExpectZRangeWithScores(RetrySet, 10, 19).SetVal([]redis.Z{
+		{Score: 100, Member: string(jobJSON)},
+		{Score: 200, Member: "invalid-json"},
+	})
+
+	entries, err := client.ListSet(RetrySet, 10, 10)
+	if err != nil {
+		t.Fatalf("ListSet failed: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Job.JID != "jid-1" || entries[0].Score != 100 {
+		t.Errorf("Expected only the valid entry, got %+v", entries)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_FindInSet(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	// A JID that is a prefix of another must not match the longer one
+	other, _ := json.Marshal(&job.SidekiqJob{JID: "jid-10"})
+	target, _ := json.Marshal(&job.SidekiqJob{JID: "jid-1"})
+
+	mock.ExpectZScan(DeadSet, 0, "*jid-1*", 100).SetVal([]string{string(other), "100"}, 7)
+	mock.ExpectZScan(DeadSet, 7, "*jid-1*", 100).SetVal([]string{string(target), "200"}, 0)
+
+	entry, err := client.FindInSet(DeadSet, "jid-1")
+	if err != nil {
+		t.Fatalf("FindInSet failed: %v", err)
+	}
+	if entry.Job.JID != "jid-1" || entry.Score != 200 {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	mock.ExpectZScan(DeadSet, 0, "*missing*", 100).SetVal([]string{}, 0)
+	if _, err := client.FindInSet(DeadSet, "missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_RetryFromSet(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	member, _ := json.Marshal(&job.SidekiqJob{JID: "jid-1", Queue: "default"})
+	expectRequeue := func(moved int64) {
+		mock.ExpectZScan(RetrySet, 0, "*jid-1*", 100).SetVal([]string{string(member), "100"}, 0)
+		mock.CustomMatch(func(expected, actual []interface{}) error {
+			want := []interface{}{"evalsha", requeueScript.Hash(), "3", RetrySet, "queue:default", "queues", string(member), "default"}
+			if fmt.Sprintln(actual[:len(want)]...) != fmt.Sprintln(want...) {
+				return fmt.Errorf("expected %v, got %v", want, actual)
+			}
+			if payload := fmt.Sprint(actual[len(want)]); !strings.Contains(payload, `"jid":"jid-1"`) {
+				return fmt.Errorf("unexpected payload %s", payload)
+			}
+			return nil
+		}).ExpectEvalSha(requeueScript.Hash(), []string{"", "", ""}, "", "", "").SetVal(moved)
+	}
+
+	expectRequeue(1)
+	if err := client.RetryFromSet(RetrySet, "jid-1"); err != nil {
+		t.Fatalf("RetryFromSet failed: %v", err)
+	}
+
+	// A concurrent retry moved the job first
+	expectRequeue(0)
+	if err := client.RetryFromSet(RetrySet, "jid-1"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_KillFromSet(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	member, _ := json.Marshal(&job.SidekiqJob{JID: "jid-1", Queue: "default"})
+	expectKill := func(moved int64) {
+		mock.ExpectZScan(ScheduleSet, 0, "*jid-1*", 100).SetVal([]string{string(member), "100"}, 0)
+		mock.CustomMatch(func(expected, actual []interface{}) error {
+			want := []interface{}{"evalsha", killScript.Hash(), "2", ScheduleSet, DeadSet, string(member)}
+			if fmt.Sprintln(actual[:len(want)]...) != fmt.Sprintln(want...) {
+				return fmt.Errorf("expected %v, got %v", want, actual)
+			}
+			if payload := fmt.Sprint(actual[len(want)+1]); !strings.Contains(payload, `"failed_at"`) {
+				return fmt.Errorf("expected failed_at in %s", payload)
+			}
+			return nil
+		}).ExpectEvalSha(killScript.Hash(), []string{"", ""}, "", "", "").SetVal(moved)
+	}
+
+	expectKill(1)
+	if err := client.KillFromSet(ScheduleSet, "jid-1"); err != nil {
+		t.Fatalf("KillFromSet failed: %v", err)
+	}
+
+	// A concurrent kill moved the job first
+	expectKill(0)
+	if err := client.KillFromSet(ScheduleSet, "jid-1"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_RequeueFromSet(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	member, _ := json.Marshal(&job.SidekiqJob{JID: "jid-1", Queue: "default", Retry: 25})
+	mock.ExpectZRangeByScoreWithScores(DeadSet, &redis.ZRangeBy{Min: "100", Max: "+inf", Offset: 0, Count: 10}).
+		SetVal([]redis.Z{{Score: 150, Member: string(member)}})
+
+	entries, err := client.ScanSet(DeadSet, "100", "+inf", 0, 10)
+	if err != nil {
+		t.Fatalf("ScanSet failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+
+	entry := &entries[0]
+	entry.Job.Retry = 0
+	replayed, _ := json.Marshal(entry.Job)
+
+	mock.ExpectEvalSha(requeueScript.Hash(), []string{DeadSet, "queue:default", "queues"},
+		string(member), "default", string(replayed)).SetVal(int64(1))
+
+	moved, err := client.RequeueFromSet(DeadSet, entry)
+	if err != nil {
+		t.Fatalf("RequeueFromSet failed: %v", err)
+	}
+	if !moved {
+		t.Error("Expected entry to be moved")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"gokiq/internal/job"
+)
+
+// batchTTL is how long batch data is kept after the batch is created
+const batchTTL = 30 * 24 * time.Hour
+
+// BatchStatus describes the progress of a batch. Pending counts jobs that have
+// not succeeded yet, including failed jobs that are waiting to be retried.
+type BatchStatus struct {
+	BID         string   `json:"bid"`
+	Description string   `json:"description"`
+	CreatedAt   float64  `json:"created_at"`
+	Total       int64    `json:"total"`
+	Pending     int64    `json:"pending"`
+	Failures    int64    `json:"failures"`
+	FailedJIDs  []string `json:"failed_jids"`
+	CompleteAt  float64  `json:"complete_at,omitempty"`
+	SuccessAt   float64  `json:"success_at,omitempty"`
+}
+
+// batchKey is the hash holding a batch's counters and callbacks
+func batchKey(bid string) string {
+	return "b-" + bid
+}
+
+// batchJobsKey is the set of JIDs in a batch that have not succeeded yet
+func batchJobsKey(bid string) string {
+	return "b-" + bid + "-jids"
+}
+
+// batchFailedKey is the set of JIDs in a batch whose last execution failed
+func batchFailedKey(bid string) string {
+	return "b-" + bid + "-failed"
+}
+
+// batchOutcomeScript records a job outcome and, the first time a batch becomes
+// complete (every pending job has failed at least once) or successful (nothing
+// pending), pushes the matching callback job. The callback payload is stored
+// with "enqueued_at":0, which is replaced by the time it is pushed. KEYS[4] is
+// the queues set and KEYS[5..] the callback queues named by ARGV[4..].
+var batchOutcomeScript = redis.NewScript(`
+if redis.call('exists', KEYS[1]) == 0 then
+	return 0
+end
+
+if ARGV[2] == 'success' then
+	if redis.call('srem', KEYS[2], ARGV[1]) == 0 then
+		return 0
+	end
+	redis.call('hincrby', KEYS[1], 'pending', -1)
+	if redis.call('srem', KEYS[3], ARGV[1]) == 1 then
+		redis.call('hincrby', KEYS[1], 'failures', -1)
+	end
+else
+	if redis.call('sismember', KEYS[2], ARGV[1]) == 0 then
+		return 0
+	end
+	if redis.call('sadd', KEYS[3], ARGV[1]) == 1 then
+		redis.call('hincrby', KEYS[1], 'failures', 1)
+		redis.call('pexpireat', KEYS[3], redis.call('hget', KEYS[1], 'expires_at'))
+	end
+end
+
+local queues = {}
+for i = 5, #KEYS do
+	queues[ARGV[i - 1]] = KEYS[i]
+end
+
+local function fire(flag, callback)
+	if redis.call('hsetnx', KEYS[1], flag, ARGV[3]) == 0 then
+		return
+	end
+	local payload = redis.call('hget', KEYS[1], callback)
+	if payload then
+		local queue = redis.call('hget', KEYS[1], callback .. '_queue')
+		payload = string.gsub(payload, '"enqueued_at":0([,}])', '"enqueued_at":' .. ARGV[3] .. '%1', 1)
+		redis.call('sadd', KEYS[4], queue)
+		redis.call('lpush', queues[queue], payload)
+	end
+end
+
+local pending = tonumber(redis.call('hget', KEYS[1], 'pending'))
+local failures = tonumber(redis.call('hget', KEYS[1], 'failures'))
+if pending == failures then
+	fire('complete_at', 'on_complete')
+end
+if pending == 0 then
+	fire('success_at', 'on_success')
+end
+return 1
+`)
+
+// CreateBatch records a batch and pushes its jobs in a single transaction, so
+// no job can finish before the batch knows about every other one. onComplete
+// and onSuccess are optional callback jobs pushed when the batch finishes.
+func (c *Client) CreateBatch(bid, description string, jobs []*job.SidekiqJob, onComplete, onSuccess *job.SidekiqJob) error {
+	if len(jobs) == 0 {
+		return fmt.Errorf("batch %s has no jobs", bid)
+	}
+
+	now := time.Now()
+	stamp := float64(now.UnixNano()) / 1e9
+	fields := []interface{}{
+		"description", description,
+		"created_at", strconv.FormatFloat(stamp, 'f', -1, 64),
+		"expires_at", now.Add(batchTTL).UnixMilli(),
+		"total", len(jobs),
+		"pending", len(jobs),
+		"failures", 0,
+	}
+	callbacks := []struct {
+		name string
+		job  *job.SidekiqJob
+	}{{"on_complete", onComplete}, {"on_success", onSuccess}}
+	for _, callback := range callbacks {
+		if callback.job == nil {
+			continue
+		}
+		// The callback is stamped when it is pushed, see batchOutcomeScript
+		if err := c.prepareJob(callback.job, stamp); err != nil {
+			return err
+		}
+		callback.job.EnqueuedAt = 0
+		payload, err := json.Marshal(callback.job)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s callback: %w", callback.name, err)
+		}
+		fields = append(fields, callback.name, string(payload), callback.name+"_queue", callback.job.Queue)
+	}
+
+	pipe := c.client.TxPipeline()
+	pipe.HSet(c.ctx, c.key(batchKey(bid)), fields...)
+	pipe.Expire(c.ctx, c.key(batchKey(bid)), batchTTL)
+
+	jids := make([]interface{}, len(jobs))
+	for i, batchJob := range jobs {
+		if err := c.prepareJob(batchJob, stamp); err != nil {
+			return err
+		}
+		batchJob.BID = bid
+		jids[i] = batchJob.JID
+
+		jobJSON, err := json.Marshal(batchJob)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job: %w", err)
+		}
+		pipe.SAdd(c.ctx, c.key("queues"), batchJob.Queue)
+		pipe.LPush(c.ctx, c.queueKey(batchJob.Queue), string(jobJSON))
+	}
+	pipe.SAdd(c.ctx, c.key(batchJobsKey(bid)), jids...)
+	pipe.Expire(c.ctx, c.key(batchJobsKey(bid)), batchTTL)
+
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return fmt.Errorf("failed to create batch %s: %w", bid, err)
+	}
+	return nil
+}
+
+// RecordBatchOutcome updates a batch's counters after one of its jobs ran,
+// pushing the batch callbacks exactly once. Outcomes for jobs that already
+// succeeded, or for batches that have expired, are ignored.
+func (c *Client) RecordBatchOutcome(bid, jid string, success bool) error {
+	outcome := "failure"
+	if success {
+		outcome = "success"
+	}
+	now := strconv.FormatFloat(float64(time.Now().UnixNano())/1e9, 'f', -1, 64)
+
+	// The script may push a callback, so its queue is declared up front
+	callbackQueues, err := c.client.HMGet(c.ctx, c.key(batchKey(bid)), "on_complete_queue", "on_success_queue").Result()
+	if err != nil {
+		return fmt.Errorf("failed to read callbacks of batch %s: %w", bid, err)
+	}
+	var names []string
+	for _, queue := range callbackQueues {
+		if name, ok := queue.(string); ok {
+			names = append(names, name)
+		}
+	}
+
+	keys, queueArgs := c.scriptQueues(names)
+	err = batchOutcomeScript.Run(c.ctx, c.client,
+		append([]string{c.key(batchKey(bid)), c.key(batchJobsKey(bid)), c.key(batchFailedKey(bid))}, keys...),
+		append([]interface{}{jid, outcome, now}, queueArgs...)...).Err()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to record outcome of %s in batch %s: %w", jid, bid, err)
+	}
+	return nil
+}
+
+// scriptQueues returns the keys a script pushing to the named queues must
+// declare, the queues set followed by each distinct queue, and the queue names
+// to pass in ARGV in the same order
+func (c *Client) scriptQueues(names []string) ([]string, []interface{}) {
+	keys := []string{c.key("queues")}
+	var args []interface{}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		keys = append(keys, c.queueKey(name))
+		args = append(args, name)
+	}
+	return keys, args
+}
+
+// BatchStatus returns the progress of a batch, or nil if it does not exist
+func (c *Client) BatchStatus(bid string) (*BatchStatus, error) {
+	pipe := c.client.Pipeline()
+	fields := pipe.HGetAll(c.ctx, c.key(batchKey(bid)))
+	failed := pipe.SMembers(c.ctx, c.key(batchFailedKey(bid)))
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return nil, fmt.Errorf("failed to read batch %s: %w", bid, err)
+	}
+
+	values := fields.Val()
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	status := &BatchStatus{
+		BID:         bid,
+		Description: values["description"],
+		FailedJIDs:  failed.Val(),
+	}
+	status.CreatedAt, _ = strconv.ParseFloat(values["created_at"], 64)
+	status.Total, _ = strconv.ParseInt(values["total"], 10, 64)
+	status.Pending, _ = strconv.ParseInt(values["pending"], 10, 64)
+	status.Failures, _ = strconv.ParseInt(values["failures"], 10, 64)
+	status.CompleteAt, _ = strconv.ParseFloat(values["complete_at"], 64)
+	status.SuccessAt, _ = strconv.ParseFloat(values["success_at"], 64)
+	return status, nil
+}
@@ -0,0 +1,133 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redismock/v8"
+
+	"gokiq/internal/job"
+)
+
+func TestClient_CreateBatch(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	jobs := []*job.SidekiqJob{
+		{Class: "ImportRowJob", JID: "jid-1", Args: []interface{}{1}},
+		{Class: "ImportRowJob", JID: "jid-2", Args: []interface{}{2}, Queue: "bulk"},
+	}
+	onSuccess := &job.SidekiqJob{Class: "ImportDoneJob", JID: "jid-cb", Args: []interface{}{"bid-1"}}
+
+	mock.ExpectTxPipeline()
+	// Timestamps vary, so check the callback payload rather than every field
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		command := fmt.Sprintln(actual...)
+		if actual[1] != "b-bid-1" || !strings.Contains(command, `"enqueued_at":0}`) || !strings.Contains(command, "on_success_queue default") {
+			return fmt.Errorf("unexpected batch hash %v", actual)
+		}
+		if strings.Contains(command, "on_complete") {
+			return fmt.Errorf("unexpected on_complete callback in %v", actual)
+		}
+		return nil
+	}).ExpectHSet("b-bid-1", make([]interface{}, 16)...).SetVal(8)
+	mock.ExpectExpire("b-bid-1", batchTTL).SetVal(true)
+	mock.ExpectSAdd("queues", "default").SetVal(0)
+	mock.Regexp().ExpectLPush("queue:default", `"jid":"jid-1".*"bid":"bid-1"`).SetVal(1)
+	mock.ExpectSAdd("queues", "bulk").SetVal(1)
+	mock.Regexp().ExpectLPush("queue:bulk", `"jid":"jid-2".*"bid":"bid-1"`).SetVal(1)
+	mock.ExpectSAdd("b-bid-1-jids", "jid-1", "jid-2").SetVal(2)
+	mock.ExpectExpire("b-bid-1-jids", batchTTL).SetVal(true)
+	mock.ExpectTxPipelineExec()
+
+	if err := client.CreateBatch("bid-1", "import", jobs, nil, onSuccess); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	if jobs[1].BID != "bid-1" || jobs[1].EnqueuedAt == 0 {
+		t.Errorf("Expected jobs to be stamped with the batch, got %+v", jobs[1])
+	}
+
+	if err := client.CreateBatch("bid-2", "empty", nil, nil, nil); err == nil {
+		t.Error("Expected error for a batch without jobs")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_RecordBatchOutcome(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	for _, outcome := range []string{"success", "failure"} {
+		outcome := outcome
+		mock.ExpectHMGet("b-bid-1", "on_complete_queue", "on_success_queue").SetVal([]interface{}{nil, "callbacks"})
+		mock.CustomMatch(func(expected, actual []interface{}) error {
+			want := []interface{}{"evalsha", batchOutcomeScript.Hash(), "5",
+				"b-bid-1", "b-bid-1-jids", "b-bid-1-failed", "queues", "queue:callbacks", "jid-1", outcome}
+			if fmt.Sprintln(actual[:len(want)]...) != fmt.Sprintln(want...) || actual[len(actual)-1] != "callbacks" {
+				return fmt.Errorf("expected %v, got %v", want, actual)
+			}
+			return nil
+		}).ExpectEvalSha(batchOutcomeScript.Hash(), []string{"", "", "", "", ""}, "", "", "", "").SetVal(int64(1))
+	}
+
+	if err := client.RecordBatchOutcome("bid-1", "jid-1", true); err != nil {
+		t.Fatalf("RecordBatchOutcome failed: %v", err)
+	}
+	if err := client.RecordBatchOutcome("bid-1", "jid-1", false); err != nil {
+		t.Fatalf("RecordBatchOutcome failed: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_BatchStatus(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	mock.ExpectHGetAll("b-bid-1").SetVal(map[string]string{
+		"description": "import",
+		"created_at":  "1700000000.5",
+		"total":       "10",
+		"pending":     "2",
+		"failures":    "2",
+		"complete_at": "1700000100",
+	})
+	mock.ExpectSMembers("b-bid-1-failed").SetVal([]string{"jid-3", "jid-7"})
+	mock.ExpectHGetAll("b-missing").SetVal(map[string]string{})
+	mock.ExpectSMembers("b-missing-failed").SetVal([]string{})
+
+	status, err := client.BatchStatus("bid-1")
+	if err != nil {
+		t.Fatalf("BatchStatus failed: %v", err)
+	}
+	if status.Total != 10 || status.Pending != 2 || status.Failures != 2 || len(status.FailedJIDs) != 2 {
+		t.Errorf("Unexpected counters: %+v", status)
+	}
+	if status.CompleteAt != 1700000100 || status.SuccessAt != 0 {
+		t.Errorf("Expected complete but not successful batch, got %+v", status)
+	}
+
+	status, err = client.BatchStatus("missing")
+	if err != nil || status != nil {
+		t.Errorf("BatchStatus() for unknown batch = %+v, %v, want nil", status, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
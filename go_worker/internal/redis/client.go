@@ -2,10 +2,15 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -16,50 +21,274 @@ import (
 
 // Client implements the RedisClient interface with connection pooling
 type Client struct {
-	client *redis.Client
+	client redis.UniversalClient
 	ctx    context.Context
+
+	// prefix is prepended to every key, e.g. "myapp:" for namespace myapp or
+	// "{gokiq}:" on Redis Cluster
+	prefix string
+
+	// poolSize is the configured connections per node, for PoolStats
+	poolSize int
+
+	// offloader moves large args out of pushed jobs; nil keeps them inline
+	offloader Offloader
+
+	// encrypter encrypts the args of pushed jobs; nil leaves them in plaintext
+	encrypter Encrypter
 }
 
-// NewClient creates a new Redis client with connection pooling
+// NewClient creates a new Redis client with connection pooling, connecting to
+// a single node, a Sentinel-managed master or a Redis Cluster
 func NewClient(cfg config.RedisConfig) (*Client, error) {
+	password, err := resolvePassword(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsCfg *tls.Config
+	if cfg.TLS.Enabled || strings.HasPrefix(cfg.URL, "rediss://") {
+		if tlsCfg, err = tlsConfig(cfg.TLS); err != nil {
+			return nil, err
+		}
+	}
+	dialer := &net.Dialer{Timeout: cfg.Pool.DialTimeout, KeepAlive: 5 * time.Minute}
+
+	var client redis.UniversalClient
+
+	switch {
+	case cfg.Sentinel.MasterName != "":
+		opts := &redis.FailoverOptions{
+			MasterName:       cfg.Sentinel.MasterName,
+			SentinelAddrs:    cfg.Sentinel.Addrs,
+			SentinelUsername: cfg.Sentinel.Username,
+			SentinelPassword: cfg.Sentinel.Password,
+			Username:         cfg.Username,
+			Password:         password,
+			DB:               cfg.DB,
+			PoolSize:         cfg.Pool.Size,
+			MinIdleConns:     cfg.Pool.MinIdle,
+			MaxRetries:       cfg.Pool.MaxRetries,
+			DialTimeout:      cfg.Pool.DialTimeout,
+			ReadTimeout:      cfg.Pool.ReadTimeout,
+			WriteTimeout:     cfg.Pool.WriteTimeout,
+			PoolTimeout:      cfg.Pool.PoolTimeout,
+			IdleTimeout:      cfg.Pool.IdleTimeout,
+		}
+		if tlsCfg != nil {
+			opts.TLSConfig, opts.Dialer = tlsCfg, tlsDialer(tlsCfg, dialer)
+		}
+		client = redis.NewFailoverClient(opts)
+	case len(cfg.Cluster.Addrs) > 0:
+		opts := &redis.ClusterOptions{
+			Addrs:        cfg.Cluster.Addrs,
+			Username:     cfg.Username,
+			Password:     password,
+			PoolSize:     cfg.Pool.Size,
+			MinIdleConns: cfg.Pool.MinIdle,
+			MaxRetries:   cfg.Pool.MaxRetries,
+			DialTimeout:  cfg.Pool.DialTimeout,
+			ReadTimeout:  cfg.Pool.ReadTimeout,
+			WriteTimeout: cfg.Pool.WriteTimeout,
+			PoolTimeout:  cfg.Pool.PoolTimeout,
+			IdleTimeout:  cfg.Pool.IdleTimeout,
+		}
+		if tlsCfg != nil {
+			opts.TLSConfig, opts.Dialer = tlsCfg, tlsDialer(tlsCfg, dialer)
+		}
+		client = redis.NewClusterClient(opts)
+	default:
+		opts, err := nodeOptions(cfg, password, tlsCfg, dialer)
+		if err != nil {
+			return nil, err
+		}
+		client = redis.NewClient(opts)
+	}
+
+	ctx := context.Background()
+
+	// Test connection
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return &Client{
+		client:   client,
+		ctx:      ctx,
+		prefix:   keyPrefix(cfg),
+		poolSize: cfg.Pool.Size,
+	}, nil
+}
+
+// keyPrefix returns the prefix of every key: on Redis Cluster a hash tag, as
+// Sidekiq's queues, sets and scripts span many keys that must share one hash
+// slot, followed by the namespace
+func keyPrefix(cfg config.RedisConfig) string {
+	var prefix string
+	if cfg.Sentinel.MasterName == "" && len(cfg.Cluster.Addrs) > 0 {
+		prefix = "{" + cfg.Cluster.HashTag + "}:"
+	}
+	if cfg.Namespace != "" {
+		prefix += cfg.Namespace + ":"
+	}
+	return prefix
+}
+
+// nodeOptions builds the options for a single Redis node from a redis://,
+// rediss:// or unix:// URL or a host:port address. Username and password
+// settings take precedence over credentials in the URL.
+func nodeOptions(cfg config.RedisConfig, password string, tlsCfg *tls.Config, dialer *net.Dialer) (*redis.Options, error) {
 	var opts *redis.Options
 	var err error
 
-	if len(cfg.URL) > 8 && cfg.URL[:8] == "redis://" {
+	if strings.Contains(cfg.URL, "://") {
 		opts, err = redis.ParseURL(cfg.URL)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
 		}
 	} else {
 		opts = &redis.Options{
-			Addr:     cfg.URL,
-			Password: cfg.Password,
-			DB:       cfg.DB,
+			Addr: cfg.URL,
+			DB:   cfg.DB,
 		}
 	}
+	if cfg.Username != "" {
+		opts.Username = cfg.Username
+	}
+	if password != "" {
+		opts.Password = password
+	}
+	if tlsCfg != nil {
+		opts.TLSConfig, opts.Dialer = tlsCfg, tlsDialer(tlsCfg, dialer)
+	}
 
-	// Connection pool settings
-	opts.PoolSize = 10
-	opts.MinIdleConns = 5
-	opts.MaxRetries = 3
-	opts.DialTimeout = 5 * time.Second
-	opts.ReadTimeout = 3 * time.Second
-	opts.WriteTimeout = 3 * time.Second
-	opts.PoolTimeout = 4 * time.Second
-	opts.IdleTimeout = 5 * time.Minute
+	opts.PoolSize = cfg.Pool.Size
+	opts.MinIdleConns = cfg.Pool.MinIdle
+	opts.MaxRetries = cfg.Pool.MaxRetries
+	opts.DialTimeout = cfg.Pool.DialTimeout
+	opts.ReadTimeout = cfg.Pool.ReadTimeout
+	opts.WriteTimeout = cfg.Pool.WriteTimeout
+	opts.PoolTimeout = cfg.Pool.PoolTimeout
+	opts.IdleTimeout = cfg.Pool.IdleTimeout
+	return opts, nil
+}
 
-	client := redis.NewClient(opts)
-	ctx := context.Background()
+// resolvePassword returns the configured password, reading it from
+// password_file when set
+func resolvePassword(cfg config.RedisConfig) (string, error) {
+	if cfg.PasswordFile == "" {
+		return cfg.Password, nil
+	}
+	data, err := os.ReadFile(cfg.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Redis password file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
 
-	// Test connection
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+// tlsConfig builds the TLS settings for Redis connections, loading the CA
+// bundle and client certificate when configured
+func tlsConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
 	}
 
-	return &Client{
-		client: client,
-		ctx:    ctx,
-	}, nil
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Redis CA file %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
+
+// tlsDialer dials TLS connections, verifying each node against its own host
+// name unless a server name is configured, since Sentinel and Cluster hand out
+// node addresses at runtime
+func tlsDialer(tlsCfg *tls.Config, netDialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		cfg := tlsCfg.Clone()
+		if cfg.ServerName == "" {
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				cfg.ServerName = host
+			}
+		}
+		dialer := &tls.Dialer{NetDialer: netDialer, Config: cfg}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// PoolStats describes the Redis connection pool. Misses are commands that
+// found no idle connection and Timeouts are commands that gave up waiting for
+// one after pool_timeout, the sign of an undersized pool.
+type PoolStats struct {
+	Size       int     `json:"size"`
+	TotalConns uint32  `json:"total_conns"`
+	IdleConns  uint32  `json:"idle_conns"`
+	InUse      uint32  `json:"in_use"`
+	Hits       uint32  `json:"hits"`
+	Misses     uint32  `json:"misses"`
+	Timeouts   uint32  `json:"timeouts"`
+	Saturation float64 `json:"saturation"`
+}
+
+// PoolStats returns the current usage of the connection pool; Saturation is
+// the share of the pool in use
+func (c *Client) PoolStats() PoolStats {
+	raw := c.client.PoolStats()
+	stats := PoolStats{
+		Size:       c.poolSize,
+		TotalConns: raw.TotalConns,
+		IdleConns:  raw.IdleConns,
+		Hits:       raw.Hits,
+		Misses:     raw.Misses,
+		Timeouts:   raw.Timeouts,
+	}
+	if raw.TotalConns > raw.IdleConns {
+		stats.InUse = raw.TotalConns - raw.IdleConns
+	}
+	if stats.Size > 0 {
+		stats.Saturation = float64(stats.InUse) / float64(stats.Size)
+	}
+	return stats
+}
+
+// SetOffloader moves the args of jobs pushed by this client out of Redis
+// when they are too large
+func (c *Client) SetOffloader(offloader Offloader) {
+	c.offloader = offloader
+}
+
+// SetEncrypter encrypts the args of jobs pushed by this client
+func (c *Client) SetEncrypter(encrypter Encrypter) {
+	c.encrypter = encrypter
+}
+
+// key returns the Redis key for a Sidekiq key name
+func (c *Client) key(name string) string {
+	return c.prefix + name
+}
+
+// queueKey returns the Redis list holding a queue's jobs
+func (c *Client) queueKey(queue string) string {
+	return c.key("queue:" + queue)
 }
 
 // PollJobs polls the specified queues for new jobs using BLPOP for blocking operation
@@ -67,7 +296,7 @@ func (c *Client) PollJobs(queues []string) (*job.SidekiqJob, error) {
 	// Convert queue names to Sidekiq format (queue:name)
 	sidekiqQueues := make([]string, len(queues))
 	for i, queue := range queues {
-		sidekiqQueues[i] = fmt.Sprintf("queue:%s", queue)
+		sidekiqQueues[i] = c.queueKey(queue)
 	}
 
 	// Use BLPOP with 1 second timeout to avoid blocking indefinitely
@@ -112,9 +341,9 @@ func (c *Client) EnqueueRetry(jobToRetry *job.SidekiqJob, delay time.Duration) e
 	retryAt := time.Now().Add(delay).Unix()
 
 	if delay > 0 {
-		// Add to scheduled set for delayed retry
+		// Add to the retry set, which the worker polls for due jobs
 		score := float64(retryAt)
-		if err := c.client.ZAdd(c.ctx, "schedule", &redis.Z{
+		if err := c.client.ZAdd(c.ctx, c.key(RetrySet), &redis.Z{
 			Score:  score,
 			Member: string(jobJSON),
 		}).Err(); err != nil {
@@ -122,7 +351,7 @@ func (c *Client) EnqueueRetry(jobToRetry *job.SidekiqJob, delay time.Duration) e
 		}
 	} else {
 		// Immediate retry - add back to retry queue
-		queueName := fmt.Sprintf("queue:%s", jobToRetry.Queue)
+		queueName := c.queueKey(jobToRetry.Queue)
 		if err := c.client.LPush(c.ctx, queueName, string(jobJSON)).Err(); err != nil {
 			return fmt.Errorf("failed to enqueue retry job: %w", err)
 		}
@@ -145,7 +374,7 @@ func (c *Client) MoveToDLQ(jobToMove *job.SidekiqJob) error {
 
 	// Add to dead letter queue with current timestamp as score
 	score := float64(time.Now().Unix())
-	if err := c.client.ZAdd(c.ctx, "dead", &redis.Z{
+	if err := c.client.ZAdd(c.ctx, c.key(DeadSet), &redis.Z{
 		Score:  score,
 		Member: string(jobJSON),
 	}).Err(); err != nil {
@@ -153,7 +382,7 @@ func (c *Client) MoveToDLQ(jobToMove *job.SidekiqJob) error {
 	}
 
 	// Trim dead queue to prevent unlimited growth (keep last 10000 jobs)
-	if err := c.client.ZRemRangeByRank(c.ctx, "dead", 0, -10001).Err(); err != nil {
+	if err := c.client.ZRemRangeByRank(c.ctx, c.key(DeadSet), 0, -10001).Err(); err != nil {
 		// Log error but don't fail the operation
 		fmt.Printf("Warning: failed to trim dead letter queue: %v\n", err)
 	}
@@ -161,6 +390,65 @@ func (c *Client) MoveToDLQ(jobToMove *job.SidekiqJob) error {
 	return nil
 }
 
+// MoveToExpired adds a job skipped because it expired to the expired set,
+// keeping the newest 10000
+func (c *Client) MoveToExpired(jobToMove *job.SidekiqJob) error {
+	jobJSON, err := json.Marshal(jobToMove)
+	if err != nil {
+		return fmt.Errorf("failed to marshal expired job: %w", err)
+	}
+
+	pipe := c.client.TxPipeline()
+	pipe.ZAdd(c.ctx, c.key(ExpiredSet), &redis.Z{Score: float64(time.Now().Unix()), Member: string(jobJSON)})
+	pipe.ZRemRangeByRank(c.ctx, c.key(ExpiredSet), 0, -10001)
+
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return fmt.Errorf("failed to record expired job: %w", err)
+	}
+	return nil
+}
+
+// RequeueInterrupted pushes jobs cut off by shutdown back onto the head of
+// their queues, incrementing interrupted_count on each
+func (c *Client) RequeueInterrupted(jobs []*job.SidekiqJob) error {
+	pipe := c.client.TxPipeline()
+	for _, interrupted := range jobs {
+		interrupted.InterruptedCount++
+
+		jobJSON, err := json.Marshal(interrupted)
+		if err != nil {
+			return fmt.Errorf("failed to marshal interrupted job: %w", err)
+		}
+
+		pipe.SAdd(c.ctx, c.key("queues"), interrupted.Queue)
+		pipe.LPush(c.ctx, c.queueKey(interrupted.Queue), string(jobJSON))
+	}
+
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return fmt.Errorf("failed to requeue interrupted jobs: %w", err)
+	}
+
+	return nil
+}
+
+// PushBack returns a fetched job to the far end of its queue, so the jobs
+// already waiting are fetched before it again
+func (c *Client) PushBack(jobData *job.SidekiqJob) error {
+	jobJSON, err := json.Marshal(jobData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	pipe := c.client.TxPipeline()
+	pipe.SAdd(c.ctx, c.key("queues"), jobData.Queue)
+	pipe.RPush(c.ctx, c.queueKey(jobData.Queue), string(jobJSON))
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return fmt.Errorf("failed to push back job %s: %w", jobData.JID, err)
+	}
+
+	return nil
+}
+
 // Close closes the Redis connection
 func (c *Client) Close() error {
 	return c.client.Close()
@@ -168,7 +456,7 @@ func (c *Client) Close() error {
 
 // GetQueueSize returns the current size of a queue
 func (c *Client) GetQueueSize(queueName string) (int64, error) {
-	sidekiqQueue := fmt.Sprintf("queue:%s", queueName)
+	sidekiqQueue := c.queueKey(queueName)
 	return c.client.LLen(c.ctx, sidekiqQueue).Result()
 }
 
@@ -177,7 +465,7 @@ func (c *Client) GetScheduledJobs() ([]*job.SidekiqJob, error) {
 	now := float64(time.Now().Unix())
 
 	// Get jobs with score <= now (ready to be processed)
-	result, err := c.client.ZRangeByScoreWithScores(c.ctx, "schedule", &redis.ZRangeBy{
+	result, err := c.client.ZRangeByScoreWithScores(c.ctx, c.key(ScheduleSet), &redis.ZRangeBy{
 		Min: "-inf",
 		Max: strconv.FormatFloat(now, 'f', -1, 64),
 	}).Result()
@@ -212,10 +500,10 @@ func (c *Client) MoveScheduledToQueue(jobToMove *job.SidekiqJob) error {
 	pipe := c.client.Pipeline()
 
 	// Remove from scheduled set
-	pipe.ZRem(c.ctx, "schedule", string(jobJSON))
+	pipe.ZRem(c.ctx, c.key(ScheduleSet), string(jobJSON))
 
 	// Add to target queue
-	queueName := fmt.Sprintf("queue:%s", jobToMove.Queue)
+	queueName := c.queueKey(jobToMove.Queue)
 	pipe.LPush(c.ctx, queueName, string(jobJSON))
 
 	// Execute pipeline
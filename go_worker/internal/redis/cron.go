@@ -0,0 +1,126 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"gokiq/internal/job"
+)
+
+// cronLeaderKey holds the identity of the process enqueuing periodic jobs
+const cronLeaderKey = "cron:leader"
+
+// cronLastKey holds the Unix time of the last tick recorded for a periodic job
+func cronLastKey(name string) string {
+	return "cron:" + name + ":last"
+}
+
+// acquireLeaderScript takes the leader lock, or renews it when it is already
+// held by the same identity
+var acquireLeaderScript = redis.NewScript(`
+local owner = redis.call('get', KEYS[1])
+if owner == ARGV[1] then
+	redis.call('pexpire', KEYS[1], ARGV[2])
+	return 1
+end
+if owner then
+	return 0
+end
+redis.call('set', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+// releaseLeaderScript drops the leader lock if it is held by the identity
+var releaseLeaderScript = redis.NewScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+	return redis.call('del', KEYS[1])
+end
+return 0
+`)
+
+// cronTickScript advances the last tick of a periodic job from ARGV[1] to
+// ARGV[2] and pushes the tick payloads, but only if no other process moved it
+// first. ARGV[1] is empty for a job that has never been recorded.
+var cronTickScript = redis.NewScript(`
+if (redis.call('get', KEYS[1]) or '') ~= ARGV[1] then
+	return 0
+end
+redis.call('set', KEYS[1], ARGV[2])
+if #ARGV > 3 then
+	redis.call('sadd', KEYS[3], ARGV[3])
+	for i = 4, #ARGV do
+		redis.call('lpush', KEYS[2], ARGV[i])
+	end
+end
+return 1
+`)
+
+// AcquireCronLeader takes or renews the cron leader lock for ttl, reporting
+// whether identity holds it
+func (c *Client) AcquireCronLeader(identity string, ttl time.Duration) (bool, error) {
+	held, err := acquireLeaderScript.Run(c.ctx, c.client, []string{c.key(cronLeaderKey)}, identity, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire cron leadership: %w", err)
+	}
+	return held == 1, nil
+}
+
+// ReleaseCronLeader gives up the cron leader lock if identity holds it
+func (c *Client) ReleaseCronLeader(identity string) error {
+	if err := releaseLeaderScript.Run(c.ctx, c.client, []string{c.key(cronLeaderKey)}, identity).Err(); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to release cron leadership: %w", err)
+	}
+	return nil
+}
+
+// CronLastTick returns the Unix time of the last tick recorded for a periodic
+// job, or 0 if none has been
+func (c *Client) CronLastTick(name string) (int64, error) {
+	last, err := c.client.Get(c.ctx, c.key(cronLastKey(name))).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read last tick of %s: %w", name, err)
+	}
+	return last, nil
+}
+
+// EnqueueCronTicks records tick as the last tick of a periodic job and pushes
+// jobs (which share one queue) in the same step, provided the last tick is
+// still previous (0 for none). It reports false, pushing nothing, when another
+// process got there first, so every tick is enqueued at most once.
+func (c *Client) EnqueueCronTicks(name string, previous, tick int64, jobs []*job.SidekiqJob) (bool, error) {
+	expected := ""
+	if previous != 0 {
+		expected = strconv.FormatInt(previous, 10)
+	}
+
+	queue := "default"
+	if len(jobs) > 0 && jobs[0].Queue != "" {
+		queue = jobs[0].Queue
+	}
+	args := []interface{}{expected, tick, queue}
+	now := float64(time.Now().UnixNano()) / 1e9
+	for _, tickJob := range jobs {
+		if err := c.prepareJob(tickJob, now); err != nil {
+			return false, fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		jobJSON, err := json.Marshal(tickJob)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal job: %w", err)
+		}
+		args = append(args, string(jobJSON))
+	}
+
+	keys := []string{c.key(cronLastKey(name)), c.queueKey(queue), c.key("queues")}
+	advanced, err := cronTickScript.Run(c.ctx, c.client, keys, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue %s: %w", name, err)
+	}
+	return advanced == 1, nil
+}
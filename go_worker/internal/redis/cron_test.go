@@ -0,0 +1,125 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v8"
+
+	"gokiq/internal/job"
+)
+
+func TestClient_AcquireCronLeader(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	mock.ExpectEvalSha(acquireLeaderScript.Hash(), []string{cronLeaderKey}, "host:1:abc", int64(30000)).SetVal(int64(1))
+	mock.ExpectEvalSha(acquireLeaderScript.Hash(), []string{cronLeaderKey}, "host:2:def", int64(30000)).SetVal(int64(0))
+
+	held, err := client.AcquireCronLeader("host:1:abc", 30*time.Second)
+	if err != nil || !held {
+		t.Errorf("Expected leadership, got %v, %v", held, err)
+	}
+	held, err = client.AcquireCronLeader("host:2:def", 30*time.Second)
+	if err != nil || held {
+		t.Errorf("Expected no leadership, got %v, %v", held, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_CronLastTick(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	mock.ExpectGet("cron:nightly:last").SetVal("1700000000")
+	mock.ExpectGet("cron:hourly:last").RedisNil()
+
+	if last, err := client.CronLastTick("nightly"); err != nil || last != 1700000000 {
+		t.Errorf("Expected 1700000000, got %d, %v", last, err)
+	}
+	if last, err := client.CronLastTick("hourly"); err != nil || last != 0 {
+		t.Errorf("Expected 0 for an unrecorded job, got %d, %v", last, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_EnqueueCronTicks(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	jobs := []*job.SidekiqJob{{Class: "ReportJob", Queue: "reports", Args: []interface{}{"daily"}}}
+
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		want := []interface{}{"evalsha", cronTickScript.Hash(), "3", "cron:nightly:last", "queue:reports", "queues", "1700000000", "1700086400", "reports"}
+		if fmt.Sprintln(actual[:len(want)]...) != fmt.Sprintln(want...) {
+			return fmt.Errorf("expected %v, got %v", want, actual)
+		}
+		if payload := fmt.Sprint(actual[len(want)]); !strings.Contains(payload, `"class":"ReportJob"`) || !strings.Contains(payload, `"args":["daily"]`) {
+			return fmt.Errorf("unexpected payload %s", payload)
+		}
+		return nil
+	}).ExpectEvalSha(cronTickScript.Hash(), []string{"", "", ""}, "", "", "", "").SetVal(int64(1))
+
+	enqueued, err := client.EnqueueCronTicks("nightly", 1700000000, 1700086400, jobs)
+	if err != nil || !enqueued {
+		t.Fatalf("Expected ticks to be enqueued, got %v, %v", enqueued, err)
+	}
+	if jobs[0].JID == "" || jobs[0].EnqueuedAt == 0 {
+		t.Errorf("Expected job to be stamped, got %+v", jobs[0])
+	}
+
+	// Another leader already advanced the tick
+	mock.ExpectEvalSha(cronTickScript.Hash(), []string{"cron:nightly:last", "queue:default", "queues"}, "", int64(1700000000), "default").SetVal(int64(0))
+
+	enqueued, err = client.EnqueueCronTicks("nightly", 0, 1700000000, nil)
+	if err != nil || enqueued {
+		t.Errorf("Expected no enqueue, got %v, %v", enqueued, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_EnqueueCronTicksEncryptsArgs(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client:    db,
+		ctx:       db.Context(),
+		encrypter: fakeEncrypter{},
+	}
+
+	jobs := []*job.SidekiqJob{{Class: "ReportJob", Queue: "reports", Args: []interface{}{"daily"}}}
+
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		if payload := fmt.Sprint(actual[len(actual)-1]); !strings.Contains(payload, `"args":["ciphertext"]`) || !strings.Contains(payload, `"encryption_key":"k1"`) {
+			return fmt.Errorf("expected encrypted payload, got %s", payload)
+		}
+		return nil
+	}).ExpectEvalSha(cronTickScript.Hash(), []string{"", "", ""}, "", "", "", "").SetVal(int64(1))
+
+	if enqueued, err := client.EnqueueCronTicks("nightly", 0, 1700000000, jobs); err != nil || !enqueued {
+		t.Fatalf("Expected ticks to be enqueued, got %v, %v", enqueued, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
@@ -16,4 +16,22 @@ type RedisClient interface {
 
 	// MoveToDLQ moves a job to the dead letter queue after max retries exceeded
 	MoveToDLQ(job *job.SidekiqJob) error
+
+	// RequeueInterrupted pushes jobs cut off by shutdown back onto the head of their queues
+	RequeueInterrupted(jobs []*job.SidekiqJob) error
+
+	// MoveToExpired records a job that was skipped because it expired
+	MoveToExpired(job *job.SidekiqJob) error
+}
+
+// Offloader moves large job args out of Redis before a job is pushed
+type Offloader interface {
+	// Offload replaces the args of job with a reference when they are too large to keep inline
+	Offload(job *job.SidekiqJob) error
+}
+
+// Encrypter encrypts job args before a job is pushed
+type Encrypter interface {
+	// Encrypt replaces the args of job with their ciphertext
+	Encrypt(job *job.SidekiqJob) error
 }
@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"gokiq/internal/job"
+)
+
+// outcomeKey is where the result of a sidecar request is stored by idempotency key
+func outcomeKey(idempotencyKey string) string {
+	return "outcome:" + idempotencyKey
+}
+
+// JobOutcome returns the recorded result for an idempotency key, or nil if
+// no result has been recorded
+func (c *Client) JobOutcome(idempotencyKey string) (*job.JobResult, error) {
+	data, err := c.client.Get(c.ctx, c.key(outcomeKey(idempotencyKey))).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outcome %s: %w", idempotencyKey, err)
+	}
+
+	var result job.JobResult
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal outcome %s: %w", idempotencyKey, err)
+	}
+	return &result, nil
+}
+
+// RecordJobOutcome stores the result of a sidecar request for ttl
+func (c *Client) RecordJobOutcome(idempotencyKey string, result *job.JobResult, ttl time.Duration) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outcome: %w", err)
+	}
+
+	if err := c.client.Set(c.ctx, c.key(outcomeKey(idempotencyKey)), string(data), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to record outcome %s: %w", idempotencyKey, err)
+	}
+	return nil
+}
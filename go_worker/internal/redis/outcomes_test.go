@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v8"
+
+	"gokiq/internal/job"
+)
+
+func TestClient_JobOutcome(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	mock.ExpectSet("outcome:jid-1-0", `{"status":"success","result":"ok","execution_time":0.5}`, time.Hour).SetVal("OK")
+	mock.ExpectGet("outcome:jid-1-0").SetVal(`{"status":"success","result":"ok","execution_time":0.5}`)
+	mock.ExpectGet("outcome:jid-2-0").RedisNil()
+
+	err := client.RecordJobOutcome("jid-1-0", &job.JobResult{Status: "success", Result: "ok", ExecutionTime: 0.5}, time.Hour)
+	if err != nil {
+		t.Fatalf("RecordJobOutcome failed: %v", err)
+	}
+
+	result, err := client.JobOutcome("jid-1-0")
+	if err != nil || result == nil || result.Result != "ok" {
+		t.Errorf("JobOutcome() = %+v, %v", result, err)
+	}
+
+	result, err = client.JobOutcome("jid-2-0")
+	if err != nil || result != nil {
+		t.Errorf("JobOutcome() for unknown key = %+v, %v, want nil", result, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
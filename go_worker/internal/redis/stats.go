@@ -0,0 +1,257 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"gokiq/internal/job"
+)
+
+// processTTL is how long a process entry survives without a heartbeat
+const processTTL = 60 * time.Second
+
+// Stats summarises the global Sidekiq counters and set sizes
+type Stats struct {
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+	Expired   int64 `json:"expired"`
+	Enqueued  int64 `json:"enqueued"`
+	Scheduled int64 `json:"scheduled"`
+	Retries   int64 `json:"retries"`
+	Dead      int64 `json:"dead"`
+	Processes int64 `json:"processes"`
+}
+
+// ProcessInfo is the static description of a worker process, stored as
+// Sidekiq's "info" field so the Web UI can display it
+type ProcessInfo struct {
+	Identity    string   `json:"identity"`
+	Hostname    string   `json:"hostname"`
+	PID         int      `json:"pid"`
+	Tag         string   `json:"tag"`
+	StartedAt   float64  `json:"started_at"`
+	Concurrency int      `json:"concurrency"`
+	Queues      []string `json:"queues"`
+	Labels      []string `json:"labels"`
+}
+
+// Process is a live worker process as reported by its last heartbeat
+type Process struct {
+	Info  ProcessInfo `json:"info"`
+	Busy  int64       `json:"busy"`
+	Beat  float64     `json:"beat"`
+	Quiet bool        `json:"quiet"`
+}
+
+// Stats returns the global counters and set sizes
+func (c *Client) Stats() (*Stats, error) {
+	queues, err := c.client.SMembers(c.ctx, c.key("queues")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+
+	pipe := c.client.Pipeline()
+	processed := pipe.Get(c.ctx, c.key("stat:processed"))
+	failed := pipe.Get(c.ctx, c.key("stat:failed"))
+	expired := pipe.Get(c.ctx, c.key("stat:expired"))
+	scheduled := pipe.ZCard(c.ctx, c.key(ScheduleSet))
+	retries := pipe.ZCard(c.ctx, c.key(RetrySet))
+	dead := pipe.ZCard(c.ctx, c.key(DeadSet))
+	processes := pipe.SCard(c.ctx, c.key("processes"))
+	sizes := make([]*redis.IntCmd, len(queues))
+	for i, queue := range queues {
+		sizes[i] = pipe.LLen(c.ctx, c.queueKey(queue))
+	}
+	if _, err := pipe.Exec(c.ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read stats: %w", err)
+	}
+
+	stats := &Stats{
+		Scheduled: scheduled.Val(),
+		Retries:   retries.Val(),
+		Dead:      dead.Val(),
+		Processes: processes.Val(),
+	}
+	// Counters are missing until the first job has been recorded
+	stats.Processed, _ = processed.Int64()
+	stats.Failed, _ = failed.Int64()
+	stats.Expired, _ = expired.Int64()
+	for _, size := range sizes {
+		stats.Enqueued += size.Val()
+	}
+
+	return stats, nil
+}
+
+// Heartbeat registers a worker process and flushes its processed, failed and
+// expired counts since the previous heartbeat into the global stats
+func (c *Client) Heartbeat(info ProcessInfo, busy int, quiet bool, processed, failed, expired int64) error {
+	infoJSON, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to marshal process info: %w", err)
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	pipe := c.client.TxPipeline()
+	if processed > 0 {
+		pipe.IncrBy(c.ctx, c.key("stat:processed"), processed)
+		pipe.IncrBy(c.ctx, c.key("stat:processed:"+today), processed)
+	}
+	if failed > 0 {
+		pipe.IncrBy(c.ctx, c.key("stat:failed"), failed)
+		pipe.IncrBy(c.ctx, c.key("stat:failed:"+today), failed)
+	}
+	if expired > 0 {
+		pipe.IncrBy(c.ctx, c.key("stat:expired"), expired)
+		pipe.IncrBy(c.ctx, c.key("stat:expired:"+today), expired)
+	}
+	pipe.SAdd(c.ctx, c.key("processes"), info.Identity)
+	pipe.HSet(c.ctx, c.key(info.Identity),
+		"info", string(infoJSON),
+		"busy", busy,
+		"beat", float64(time.Now().UnixNano())/1e9,
+		"quiet", strconv.FormatBool(quiet),
+	)
+	pipe.Expire(c.ctx, c.key(info.Identity), processTTL)
+
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveProcess unregisters a worker process
+func (c *Client) RemoveProcess(identity string) error {
+	pipe := c.client.TxPipeline()
+	pipe.SRem(c.ctx, c.key("processes"), identity)
+	pipe.Del(c.ctx, c.key(identity))
+
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return fmt.Errorf("failed to remove process %s: %w", identity, err)
+	}
+
+	return nil
+}
+
+// Processes returns every worker process with a live heartbeat, pruning
+// entries whose heartbeat has expired
+func (c *Client) Processes() ([]Process, error) {
+	identities, err := c.client.SMembers(c.ctx, c.key("processes")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	sort.Strings(identities)
+
+	processes := make([]Process, 0, len(identities))
+	for _, identity := range identities {
+		fields, err := c.client.HGetAll(c.ctx, c.key(identity)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read process %s: %w", identity, err)
+		}
+
+		if len(fields) == 0 {
+			// Heartbeat expired, the process is gone
+			c.client.SRem(c.ctx, c.key("processes"), identity)
+			continue
+		}
+
+		var process Process
+		if err := json.Unmarshal([]byte(fields["info"]), &process.Info); err != nil {
+			continue
+		}
+		process.Busy, _ = strconv.ParseInt(fields["busy"], 10, 64)
+		process.Beat, _ = strconv.ParseFloat(fields["beat"], 64)
+		process.Quiet, _ = strconv.ParseBool(fields["quiet"])
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+// Enqueue pushes a new job onto its queue
+func (c *Client) Enqueue(newJob *job.SidekiqJob) error {
+	if err := c.prepareJob(newJob, float64(time.Now().UnixNano())/1e9); err != nil {
+		return err
+	}
+
+	jobJSON, err := json.Marshal(newJob)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	pipe := c.client.TxPipeline()
+	pipe.SAdd(c.ctx, c.key("queues"), newJob.Queue)
+	pipe.LPush(c.ctx, c.queueKey(newJob.Queue), string(jobJSON))
+
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return nil
+}
+
+// stampJob fills in the JID, queue and timestamps of a job about to be pushed
+func stampJob(newJob *job.SidekiqJob, now float64) {
+	if newJob.JID == "" {
+		newJob.JID = job.NewJID()
+	}
+	if newJob.Queue == "" {
+		newJob.Queue = "default"
+	}
+	if newJob.CreatedAt == 0 {
+		newJob.CreatedAt = now
+	}
+	newJob.EnqueuedAt = now
+}
+
+// prepareJob stamps a job about to be pushed, encrypts its args and offloads
+// them if they are too large. Encrypting first keeps blobs encrypted too.
+func (c *Client) prepareJob(newJob *job.SidekiqJob, now float64) error {
+	stampJob(newJob, now)
+	if c.encrypter != nil {
+		if err := c.encrypter.Encrypt(newJob); err != nil {
+			return err
+		}
+	}
+	if c.offloader != nil {
+		return c.offloader.Offload(newJob)
+	}
+	return nil
+}
+
+// signalsKey is the list the Sidekiq Web UI pushes Quiet/Stop requests onto
+func signalsKey(identity string) string {
+	return identity + "-signals"
+}
+
+// PopSignal returns the oldest pending remote signal for a process, or an
+// empty string when there is none
+func (c *Client) PopSignal(identity string) (string, error) {
+	signal, err := c.client.RPop(c.ctx, c.key(signalsKey(identity))).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read signals for %s: %w", identity, err)
+	}
+	return signal, nil
+}
+
+// SendSignal asks a process to handle a signal such as TSTP or TERM on its
+// next heartbeat, the same way the Sidekiq Web UI does
+func (c *Client) SendSignal(identity, signal string) error {
+	pipe := c.client.TxPipeline()
+	pipe.LPush(c.ctx, c.key(signalsKey(identity)), signal)
+	pipe.Expire(c.ctx, c.key(signalsKey(identity)), processTTL)
+
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return fmt.Errorf("failed to signal process %s: %w", identity, err)
+	}
+	return nil
+}
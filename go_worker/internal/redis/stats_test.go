@@ -0,0 +1,211 @@
+package redis
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v8"
+
+	"gokiq/internal/job"
+)
+
+func TestClient_Stats(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	mock.ExpectSMembers("queues").SetVal([]string{"default", "low"})
+	mock.ExpectGet("stat:processed").SetVal("42")
+	mock.ExpectGet("stat:failed").SetVal("7")
+	mock.ExpectGet("stat:expired").SetVal("8")
+	mock.ExpectZCard(ScheduleSet).SetVal(1)
+	mock.ExpectZCard(RetrySet).SetVal(2)
+	mock.ExpectZCard(DeadSet).SetVal(3)
+	mock.ExpectSCard("processes").SetVal(4)
+	mock.ExpectLLen("queue:default").SetVal(5)
+	mock.ExpectLLen("queue:low").SetVal(6)
+
+	stats, err := client.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+
+	want := Stats{Processed: 42, Failed: 7, Expired: 8, Enqueued: 11, Scheduled: 1, Retries: 2, Dead: 3, Processes: 4}
+	if *stats != want {
+		t.Errorf("Stats() = %+v, want %+v", *stats, want)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_Processes(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	info, _ := json.Marshal(ProcessInfo{Identity: "host:1:abc", Concurrency: 10})
+	mock.ExpectSMembers("processes").SetVal([]string{"host:1:abc", "host:2:gone"})
+	mock.ExpectHGetAll("host:1:abc").SetVal(map[string]string{
+		"info":  string(info),
+		"busy":  "3",
+		"beat":  "1700000000.5",
+		"quiet": "true",
+	})
+	mock.ExpectHGetAll("host:2:gone").SetVal(map[string]string{})
+	mock.ExpectSRem("processes", "host:2:gone").SetVal(1)
+
+	processes, err := client.Processes()
+	if err != nil {
+		t.Fatalf("Processes failed: %v", err)
+	}
+
+	if len(processes) != 1 {
+		t.Fatalf("Expected 1 live process, got %d", len(processes))
+	}
+	p := processes[0]
+	if p.Info.Identity != "host:1:abc" || p.Info.Concurrency != 10 || p.Busy != 3 || p.Beat != 1700000000.5 || !p.Quiet {
+		t.Errorf("Unexpected process: %+v", p)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+// fakeOffloader replaces every job's args with a reference
+type fakeOffloader struct{}
+
+func (fakeOffloader) Offload(jobData *job.SidekiqJob) error {
+	jobData.ArgsRef = jobData.JID + ".json"
+	jobData.Args = []interface{}{}
+	return nil
+}
+
+// fakeEncrypter replaces every job's args with a marker
+type fakeEncrypter struct{}
+
+func (fakeEncrypter) Encrypt(jobData *job.SidekiqJob) error {
+	jobData.Args = []interface{}{"ciphertext"}
+	jobData.EncryptionKey = "k1"
+	return nil
+}
+
+func TestClient_EnqueueEncryptsArgs(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client:    db,
+		ctx:       db.Context(),
+		encrypter: fakeEncrypter{},
+	}
+
+	newJob := &job.SidekiqJob{Class: "NotifyJob", JID: "jid-1", Args: []interface{}{"alice@example.com"}}
+
+	mock.ExpectTxPipeline()
+	mock.ExpectSAdd("queues", "default").SetVal(1)
+	mock.Regexp().ExpectLPush("queue:default", `"args":\["ciphertext"\].*"encryption_key":"k1"`).SetVal(1)
+	mock.ExpectTxPipelineExec()
+
+	if err := client.Enqueue(newJob); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_EnqueueOffloadsArgs(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client:    db,
+		ctx:       db.Context(),
+		offloader: fakeOffloader{},
+	}
+
+	newJob := &job.SidekiqJob{Class: "ImportJob", JID: "jid-1", Args: []interface{}{"large"}}
+
+	mock.ExpectTxPipeline()
+	mock.ExpectSAdd("queues", "default").SetVal(1)
+	mock.Regexp().ExpectLPush("queue:default", `"args":\[\].*"args_ref":"jid-1.json"`).SetVal(1)
+	mock.ExpectTxPipelineExec()
+
+	if err := client.Enqueue(newJob); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_Enqueue(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	newJob := &job.SidekiqJob{Class: "TestJob", Args: []interface{}{1.0}}
+
+	mock.ExpectTxPipeline()
+	mock.ExpectSAdd("queues", "default").SetVal(1)
+	mock.Regexp().ExpectLPush("queue:default", `"class":"TestJob"`).SetVal(1)
+	mock.ExpectTxPipelineExec()
+
+	if err := client.Enqueue(newJob); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	if len(newJob.JID) != 24 {
+		t.Errorf("Expected generated 24 character JID, got %q", newJob.JID)
+	}
+	if newJob.Queue != "default" {
+		t.Errorf("Expected default queue, got %s", newJob.Queue)
+	}
+	if newJob.EnqueuedAt == 0 || newJob.EnqueuedAt > float64(time.Now().Unix()+1) {
+		t.Errorf("Unexpected enqueued_at: %f", newJob.EnqueuedAt)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_Signals(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	mock.ExpectTxPipeline()
+	mock.ExpectLPush("host:1:abc-signals", "TSTP").SetVal(1)
+	mock.ExpectExpire("host:1:abc-signals", processTTL).SetVal(true)
+	mock.ExpectTxPipelineExec()
+	mock.ExpectRPop("host:1:abc-signals").SetVal("TSTP")
+	mock.ExpectRPop("host:1:abc-signals").RedisNil()
+
+	if err := client.SendSignal("host:1:abc", "TSTP"); err != nil {
+		t.Fatalf("SendSignal failed: %v", err)
+	}
+
+	signal, err := client.PopSignal("host:1:abc")
+	if err != nil || signal != "TSTP" {
+		t.Errorf("PopSignal() = %q, %v, want TSTP", signal, err)
+	}
+	signal, err = client.PopSignal("host:1:abc")
+	if err != nil || signal != "" {
+		t.Errorf("PopSignal() on empty list = %q, %v, want empty", signal, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
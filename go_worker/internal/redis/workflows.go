@@ -0,0 +1,310 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"gokiq/internal/job"
+)
+
+const (
+	// workflowTTL is how long workflow data is kept after it is created
+	workflowTTL = 30 * 24 * time.Hour
+
+	// WorkflowSet indexes workflows by creation time
+	WorkflowSet = "workflows"
+)
+
+// WorkflowStep is a job in a workflow and the steps that must finish first
+type WorkflowStep struct {
+	Name    string
+	Job     *job.SidekiqJob
+	Parents []string
+}
+
+// WorkflowStatus describes a workflow and each of its steps. Status is
+// running, succeeded, failed (finished with failed steps) or halted.
+type WorkflowStatus struct {
+	ID          string               `json:"id"`
+	Description string               `json:"description"`
+	Policy      string               `json:"policy"`
+	Status      string               `json:"status"`
+	CreatedAt   float64              `json:"created_at"`
+	FinishedAt  float64              `json:"finished_at,omitempty"`
+	Steps       []WorkflowStepStatus `json:"steps"`
+}
+
+// WorkflowStepStatus describes one step. Status is pending, enqueued,
+// succeeded, failed, skipped or cancelled.
+type WorkflowStepStatus struct {
+	Name    string   `json:"name"`
+	Class   string   `json:"class"`
+	JID     string   `json:"jid"`
+	Parents []string `json:"parents"`
+	Status  string   `json:"status"`
+}
+
+// workflowKey is the hash holding a workflow's state. Step fields are
+// prefixed with the step name, e.g. "extract.status".
+func workflowKey(id string) string {
+	return "wf-" + id
+}
+
+// workflowOutcomeScript records that an enqueued step succeeded or failed for
+// good, then applies the workflow's failure policy and pushes every step whose
+// parents have all finished. Payloads are stored with "enqueued_at":0, which
+// is replaced by the time they are pushed. KEYS[2] is the queues set and
+// KEYS[3..] the workflow's queues named by ARGV[4..].
+var workflowOutcomeScript = redis.NewScript(`
+local wf = KEYS[1]
+local step = ARGV[1]
+if redis.call('hget', wf, step .. '.status') ~= 'enqueued' then
+	return 0
+end
+
+local queues = {}
+for i = 3, #KEYS do
+	queues[ARGV[i + 1]] = KEYS[i]
+end
+
+local function children(name)
+	local result = {}
+	local list = redis.call('hget', wf, name .. '.children') or ''
+	for child in string.gmatch(list, '[^,]+') do
+		table.insert(result, child)
+	end
+	return result
+end
+
+local function finish(name, status)
+	redis.call('hset', wf, name .. '.status', status)
+	if redis.call('hincrby', wf, 'remaining', -1) > 0 then
+		return
+	end
+	redis.call('hset', wf, 'finished_at', ARGV[3])
+	if redis.call('hget', wf, 'status') == 'running' then
+		if tonumber(redis.call('hget', wf, 'failures')) > 0 then
+			redis.call('hset', wf, 'status', 'failed')
+		else
+			redis.call('hset', wf, 'status', 'succeeded')
+		end
+	end
+end
+
+local function release(name)
+	if redis.call('hget', wf, 'status') ~= 'running' then
+		return
+	end
+	for _, child in ipairs(children(name)) do
+		if redis.call('hincrby', wf, child .. '.waiting', -1) == 0 and redis.call('hget', wf, child .. '.status') == 'pending' then
+			local queue = redis.call('hget', wf, child .. '.queue')
+			local payload = redis.call('hget', wf, child .. '.payload')
+			payload = string.gsub(payload, '"enqueued_at":0([,}])', '"enqueued_at":' .. ARGV[3] .. '%1', 1)
+			redis.call('hset', wf, child .. '.status', 'enqueued')
+			redis.call('sadd', KEYS[2], queue)
+			redis.call('lpush', queues[queue], payload)
+		end
+	end
+end
+
+local function skip(name)
+	for _, child in ipairs(children(name)) do
+		if redis.call('hget', wf, child .. '.status') == 'pending' then
+			finish(child, 'skipped')
+			skip(child)
+		end
+	end
+end
+
+if ARGV[2] == 'success' then
+	finish(step, 'succeeded')
+	release(step)
+	return 1
+end
+
+redis.call('hincrby', wf, 'failures', 1)
+local policy = redis.call('hget', wf, 'policy')
+if policy == 'continue' then
+	finish(step, 'failed')
+	release(step)
+elseif policy == 'skip' then
+	finish(step, 'failed')
+	skip(step)
+else
+	if redis.call('hget', wf, 'status') == 'running' then
+		redis.call('hset', wf, 'status', 'halted')
+	end
+	finish(step, 'failed')
+	for name in string.gmatch(redis.call('hget', wf, 'steps'), '[^,]+') do
+		if redis.call('hget', wf, name .. '.status') == 'pending' then
+			finish(name, 'cancelled')
+		end
+	end
+end
+return 1
+`)
+
+// CreateWorkflow records a workflow and pushes the steps without parents in a
+// single transaction. Steps must be listed after their parents and names must
+// not contain commas; the workflow package checks both.
+func (c *Client) CreateWorkflow(id, description, policy string, steps []WorkflowStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("workflow %s has no steps", id)
+	}
+
+	now := time.Now()
+	stamp := float64(now.UnixNano()) / 1e9
+	names := make([]string, len(steps))
+	children := make(map[string][]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.Name
+		for _, parent := range step.Parents {
+			children[parent] = append(children[parent], step.Name)
+		}
+	}
+
+	fields := []interface{}{
+		"description", description,
+		"policy", policy,
+		"status", "running",
+		"created_at", strconv.FormatFloat(stamp, 'f', -1, 64),
+		"remaining", len(steps),
+		"failures", 0,
+		"steps", strings.Join(names, ","),
+	}
+
+	// Every queue a step may be pushed to, declared by workflowOutcomeScript
+	var queues []string
+	seenQueues := make(map[string]bool, len(steps))
+
+	pipe := c.client.TxPipeline()
+	for _, step := range steps {
+		if err := c.prepareJob(step.Job, stamp); err != nil {
+			return err
+		}
+		if !seenQueues[step.Job.Queue] {
+			seenQueues[step.Job.Queue] = true
+			queues = append(queues, step.Job.Queue)
+		}
+		step.Job.WorkflowID = id
+		step.Job.WorkflowStep = step.Name
+
+		status := "pending"
+		if len(step.Parents) == 0 {
+			status = "enqueued"
+		} else {
+			// Pushed by workflowOutcomeScript once the parents finish
+			step.Job.EnqueuedAt = 0
+		}
+
+		payload, err := json.Marshal(step.Job)
+		if err != nil {
+			return fmt.Errorf("failed to marshal step %s: %w", step.Name, err)
+		}
+		fields = append(fields,
+			step.Name+".class", step.Job.Class,
+			step.Name+".jid", step.Job.JID,
+			step.Name+".queue", step.Job.Queue,
+			step.Name+".payload", string(payload),
+			step.Name+".parents", strings.Join(step.Parents, ","),
+			step.Name+".children", strings.Join(children[step.Name], ","),
+			step.Name+".waiting", len(step.Parents),
+			step.Name+".status", status,
+		)
+
+		if status == "enqueued" {
+			pipe.SAdd(c.ctx, c.key("queues"), step.Job.Queue)
+			pipe.LPush(c.ctx, c.queueKey(step.Job.Queue), string(payload))
+		}
+	}
+	fields = append(fields, "queues", strings.Join(queues, ","))
+	pipe.HSet(c.ctx, c.key(workflowKey(id)), fields...)
+	pipe.Expire(c.ctx, c.key(workflowKey(id)), workflowTTL)
+	pipe.ZAdd(c.ctx, c.key(WorkflowSet), &redis.Z{Score: stamp, Member: id})
+	pipe.ZRemRangeByScore(c.ctx, c.key(WorkflowSet), "-inf", strconv.FormatFloat(stamp-workflowTTL.Seconds(), 'f', -1, 64))
+
+	if _, err := pipe.Exec(c.ctx); err != nil {
+		return fmt.Errorf("failed to create workflow %s: %w", id, err)
+	}
+	return nil
+}
+
+// RecordStepOutcome records that a workflow step succeeded, or failed with no
+// retries left, and pushes the steps that became ready. Outcomes for steps that
+// are not enqueued, or for workflows that have expired, are ignored.
+func (c *Client) RecordStepOutcome(id, step string, success bool) error {
+	outcome := "failure"
+	if success {
+		outcome = "success"
+	}
+	now := strconv.FormatFloat(float64(time.Now().UnixNano())/1e9, 'f', -1, 64)
+
+	// The script may push any step, so every queue of the workflow is declared
+	queues, err := c.client.HGet(c.ctx, c.key(workflowKey(id)), "queues").Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to read queues of workflow %s: %w", id, err)
+	}
+
+	keys, queueArgs := c.scriptQueues(splitNames(queues))
+	err = workflowOutcomeScript.Run(c.ctx, c.client,
+		append([]string{c.key(workflowKey(id))}, keys...),
+		append([]interface{}{step, outcome, now}, queueArgs...)...).Err()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to record outcome of step %s in workflow %s: %w", step, id, err)
+	}
+	return nil
+}
+
+// Workflow returns the state of a workflow, or nil if it does not exist
+func (c *Client) Workflow(id string) (*WorkflowStatus, error) {
+	values, err := c.client.HGetAll(c.ctx, c.key(workflowKey(id))).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow %s: %w", id, err)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	status := &WorkflowStatus{
+		ID:          id,
+		Description: values["description"],
+		Policy:      values["policy"],
+		Status:      values["status"],
+		Steps:       []WorkflowStepStatus{},
+	}
+	status.CreatedAt, _ = strconv.ParseFloat(values["created_at"], 64)
+	status.FinishedAt, _ = strconv.ParseFloat(values["finished_at"], 64)
+
+	for _, name := range splitNames(values["steps"]) {
+		status.Steps = append(status.Steps, WorkflowStepStatus{
+			Name:    name,
+			Class:   values[name+".class"],
+			JID:     values[name+".jid"],
+			Parents: splitNames(values[name+".parents"]),
+			Status:  values[name+".status"],
+		})
+	}
+	return status, nil
+}
+
+// Workflows returns the IDs of the most recently created workflows, newest first
+func (c *Client) Workflows(offset, count int64) ([]string, error) {
+	ids, err := c.client.ZRevRange(c.ctx, c.key(WorkflowSet), offset, offset+count-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+	return ids, nil
+}
+
+// splitNames splits a comma separated list, returning an empty slice for ""
+func splitNames(list string) []string {
+	if list == "" {
+		return []string{}
+	}
+	return strings.Split(list, ",")
+}
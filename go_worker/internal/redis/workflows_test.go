@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+
+	"gokiq/internal/job"
+)
+
+func TestClient_CreateWorkflow(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	steps := []WorkflowStep{
+		{Name: "extract", Job: &job.SidekiqJob{Class: "ExtractJob", JID: "jid-a"}},
+		{Name: "load", Job: &job.SidekiqJob{Class: "LoadJob", JID: "jid-b", Queue: "etl"}, Parents: []string{"extract"}},
+	}
+
+	// Timestamps vary, so only the command shape is matched exactly
+	anything := func(expected, actual []interface{}) error { return nil }
+
+	mock.ExpectTxPipeline()
+	mock.ExpectSAdd("queues", "default").SetVal(0)
+	mock.Regexp().ExpectLPush("queue:default", `"jid":"jid-a".*"wid":"wf-1","wf_step":"extract"`).SetVal(1)
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		command := fmt.Sprintln(actual...)
+		for _, want := range []string{"policy skip", "steps extract,load", "extract.children load", "extract.status enqueued", "load.status pending", "load.waiting 1", "queues default,etl", `"enqueued_at":0,`} {
+			if !strings.Contains(command, want) {
+				return fmt.Errorf("expected %q in %v", want, actual)
+			}
+		}
+		return nil
+	}).ExpectHSet("wf-wf-1", make([]interface{}, 48)...).SetVal(24)
+	mock.ExpectExpire("wf-wf-1", workflowTTL).SetVal(true)
+	mock.CustomMatch(anything).ExpectZAdd(WorkflowSet, &redis.Z{}).SetVal(1)
+	mock.CustomMatch(anything).ExpectZRemRangeByScore(WorkflowSet, "", "").SetVal(0)
+	mock.ExpectTxPipelineExec()
+
+	if err := client.CreateWorkflow("wf-1", "etl", "skip", steps); err != nil {
+		t.Fatalf("CreateWorkflow failed: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_RecordStepOutcome(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	mock.ExpectHGet("wf-wf-1", "queues").SetVal("default,etl")
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		want := []interface{}{"evalsha", workflowOutcomeScript.Hash(), "4",
+			"wf-wf-1", "queues", "queue:default", "queue:etl", "load", "failure"}
+		if fmt.Sprintln(actual[:len(want)]...) != fmt.Sprintln(want...) || fmt.Sprintln(actual[len(actual)-2:]...) != fmt.Sprintln("default", "etl") {
+			return fmt.Errorf("expected %v, got %v", want, actual)
+		}
+		return nil
+	}).ExpectEvalSha(workflowOutcomeScript.Hash(), []string{"", "", "", ""}, "", "", "", "", "").SetVal(int64(1))
+
+	if err := client.RecordStepOutcome("wf-1", "load", false); err != nil {
+		t.Fatalf("RecordStepOutcome failed: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
+
+func TestClient_Workflow(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	client := &Client{
+		client: db,
+		ctx:    db.Context(),
+	}
+
+	mock.ExpectHGetAll("wf-wf-1").SetVal(map[string]string{
+		"description":     "etl",
+		"policy":          "halt",
+		"status":          "halted",
+		"created_at":      "1700000000",
+		"steps":           "extract,load",
+		"extract.class":   "ExtractJob",
+		"extract.jid":     "jid-a",
+		"extract.parents": "",
+		"extract.status":  "failed",
+		"load.class":      "LoadJob",
+		"load.jid":        "jid-b",
+		"load.parents":    "extract",
+		"load.status":     "cancelled",
+	})
+	mock.ExpectHGetAll("wf-missing").SetVal(map[string]string{})
+
+	workflow, err := client.Workflow("wf-1")
+	if err != nil {
+		t.Fatalf("Workflow failed: %v", err)
+	}
+	if workflow.Status != "halted" || len(workflow.Steps) != 2 {
+		t.Fatalf("Unexpected workflow: %+v", workflow)
+	}
+	load := workflow.Steps[1]
+	if load.Class != "LoadJob" || load.Status != "cancelled" || len(load.Parents) != 1 || load.Parents[0] != "extract" {
+		t.Errorf("Unexpected step: %+v", load)
+	}
+	if len(workflow.Steps[0].Parents) != 0 {
+		t.Errorf("Expected root step without parents, got %v", workflow.Steps[0].Parents)
+	}
+
+	workflow, err = client.Workflow("missing")
+	if err != nil || workflow != nil {
+		t.Errorf("Workflow() for unknown id = %+v, %v, want nil", workflow, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Redis mock expectations not met: %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+package replay
+
+import "gokiq/internal/redis"
+
+// Store defines the Redis operations required to replay dead jobs
+type Store interface {
+	// ScanSet returns entries with scores between min and max without modifying the set
+	ScanSet(set, min, max string, offset, count int64) ([]redis.SetEntry, error)
+
+	// RequeueFromSet atomically moves an entry from a sorted set back onto its queue
+	RequeueFromSet(set string, entry *redis.SetEntry) (bool, error)
+}
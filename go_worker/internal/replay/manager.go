@@ -0,0 +1,129 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gokiq/internal/job"
+)
+
+// maxFinished is the number of finished replays kept for inspection
+const maxFinished = 100
+
+// Manager runs replays in the background and tracks their progress
+type Manager struct {
+	store   Store
+	mu      sync.RWMutex
+	replays map[string]*tracked
+}
+
+// tracked is a replay started by the manager
+type tracked struct {
+	progress Progress
+	cancel   context.CancelFunc
+}
+
+// NewManager creates a new replay manager
+func NewManager(store Store) *Manager {
+	return &Manager{
+		store:   store,
+		replays: make(map[string]*tracked),
+	}
+}
+
+// Start validates opts and begins a replay in the background, returning its ID.
+// Replaying the whole dead set must be confirmed with opts.All.
+func (m *Manager) Start(opts Options) (string, error) {
+	if _, err := opts.compile(); err != nil {
+		return "", err
+	}
+	if !opts.Filtered() && !opts.All && !opts.DryRun {
+		return "", fmt.Errorf("expected a filter or all to replay every dead job")
+	}
+
+	id := job.NewJID()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.mu.Lock()
+	m.replays[id] = &tracked{progress: Progress{ID: id, DryRun: opts.DryRun, StartedAt: time.Now()}, cancel: cancel}
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		Run(ctx, m.store, opts, func(p Progress) {
+			p.ID = id
+			m.mu.Lock()
+			m.replays[id].progress = p
+			m.mu.Unlock()
+		})
+		m.prune()
+	}()
+
+	return id, nil
+}
+
+// prune forgets the oldest finished replays beyond maxFinished
+func (m *Manager) prune() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var finished []*tracked
+	for _, t := range m.replays {
+		if t.progress.Done {
+			finished = append(finished, t)
+		}
+	}
+	if len(finished) <= maxFinished {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].progress.FinishedAt.Before(finished[j].progress.FinishedAt)
+	})
+	for _, t := range finished[:len(finished)-maxFinished] {
+		delete(m.replays, t.progress.ID)
+	}
+}
+
+// Get returns the latest progress of a replay
+func (m *Manager) Get(id string) (Progress, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.replays[id]
+	if !ok {
+		return Progress{}, false
+	}
+	return t.progress, true
+}
+
+// List returns the progress of every replay started by this manager
+func (m *Manager) List() []Progress {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]Progress, 0, len(m.replays))
+	for _, t := range m.replays {
+		list = append(list, t.progress)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartedAt.Before(list[j].StartedAt)
+	})
+	return list
+}
+
+// Cancel stops a running replay
+func (m *Manager) Cancel(id string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.replays[id]
+	if !ok {
+		return false
+	}
+	t.cancel()
+	return true
+}
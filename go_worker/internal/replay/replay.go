@@ -0,0 +1,245 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gokiq/internal/redis"
+)
+
+// scanBatchSize is the number of dead entries read per Redis round trip
+const scanBatchSize = 500
+
+// Options selects which dead jobs to replay and how fast
+type Options struct {
+	// Class and Queue must match exactly when set
+	Class string `json:"class,omitempty"`
+	Queue string `json:"queue,omitempty"`
+
+	// ErrorClass and ErrorMessage are regular expressions matched against the job's last error
+	ErrorClass   string `json:"error_class,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+
+	// Since and Until bound the time the job died
+	Since time.Time `json:"since,omitempty"`
+	Until time.Time `json:"until,omitempty"`
+
+	// Rate limits replays per second; zero means unthrottled
+	Rate float64 `json:"rate,omitempty"`
+
+	// Limit caps the number of jobs replayed; zero means no limit
+	Limit int64 `json:"limit,omitempty"`
+
+	// DryRun counts matching jobs without replaying them
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// All confirms replaying the whole dead set when no filter is given
+	All bool `json:"all,omitempty"`
+}
+
+// Filtered reports whether any filter narrows down the jobs to replay
+func (o Options) Filtered() bool {
+	return o.Class != "" || o.Queue != "" || o.ErrorClass != "" ||
+		o.ErrorMessage != "" || !o.Since.IsZero() || !o.Until.IsZero()
+}
+
+// Progress reports how far a replay has got
+type Progress struct {
+	ID         string    `json:"id,omitempty"`
+	Scanned    int64     `json:"scanned"`
+	Matched    int64     `json:"matched"`
+	Replayed   int64     `json:"replayed"`
+	Skipped    int64     `json:"skipped"`
+	Failed     int64     `json:"failed"`
+	DryRun     bool      `json:"dry_run"`
+	Done       bool      `json:"done"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+}
+
+// filter is the compiled form of Options
+type filter struct {
+	class        string
+	queue        string
+	errorClass   *regexp.Regexp
+	errorMessage *regexp.Regexp
+}
+
+// compile validates the options and builds a filter
+func (o Options) compile() (*filter, error) {
+	f := &filter{class: o.Class, queue: o.Queue}
+
+	var err error
+	if o.ErrorClass != "" {
+		if f.errorClass, err = regexp.Compile(o.ErrorClass); err != nil {
+			return nil, fmt.Errorf("invalid error_class pattern: %w", err)
+		}
+	}
+	if o.ErrorMessage != "" {
+		if f.errorMessage, err = regexp.Compile(o.ErrorMessage); err != nil {
+			return nil, fmt.Errorf("invalid error_message pattern: %w", err)
+		}
+	}
+	if !o.Since.IsZero() && !o.Until.IsZero() && o.Until.Before(o.Since) {
+		return nil, fmt.Errorf("until must not be before since")
+	}
+	if o.Rate < 0 {
+		return nil, fmt.Errorf("rate must not be negative")
+	}
+	if o.Limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative")
+	}
+
+	return f, nil
+}
+
+// matches reports whether a dead entry satisfies the filter
+func (f *filter) matches(entry *redis.SetEntry) bool {
+	j := entry.Job
+	if f.class != "" && j.Class != f.class {
+		return false
+	}
+	if f.queue != "" && j.Queue != f.queue {
+		return false
+	}
+	if f.errorClass != nil && !f.errorClass.MatchString(j.ErrorClass) {
+		return false
+	}
+	if f.errorMessage != nil && !f.errorMessage.MatchString(j.ErrorMsg) {
+		return false
+	}
+	return true
+}
+
+// Run replays dead jobs matching opts, calling report after every job.
+// Matching jobs are re-enqueued with their retry counter and error reset.
+func Run(ctx context.Context, store Store, opts Options, report func(Progress)) (Progress, error) {
+	progress := Progress{DryRun: opts.DryRun, StartedAt: time.Now()}
+	finish := func(err error) (Progress, error) {
+		progress.Done = true
+		progress.FinishedAt = time.Now()
+		if err != nil {
+			progress.Error = err.Error()
+		}
+		if report != nil {
+			report(progress)
+		}
+		return progress, err
+	}
+
+	f, err := opts.compile()
+	if err != nil {
+		return finish(err)
+	}
+
+	// Collect matches first so removing replayed entries doesn't shift the scan
+	matches, err := collect(ctx, store, opts, f, &progress)
+	if err != nil {
+		return finish(err)
+	}
+	progress.Matched = int64(len(matches))
+	if report != nil {
+		report(progress)
+	}
+
+	if opts.DryRun {
+		return finish(nil)
+	}
+
+	var throttle <-chan time.Time
+	if opts.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	for i := range matches {
+		if i > 0 && throttle != nil {
+			select {
+			case <-ctx.Done():
+				return finish(ctx.Err())
+			case <-throttle:
+			}
+		} else if ctx.Err() != nil {
+			return finish(ctx.Err())
+		}
+
+		moved, err := Entry(store, &matches[i])
+		switch {
+		case err != nil:
+			progress.Failed++
+		case moved:
+			progress.Replayed++
+		default:
+			// Someone else deleted or replayed it first
+			progress.Skipped++
+		}
+
+		if report != nil {
+			report(progress)
+		}
+	}
+
+	return finish(nil)
+}
+
+// collect pages through the dead set and returns every entry matching the filter
+func collect(ctx context.Context, store Store, opts Options, f *filter, progress *Progress) ([]redis.SetEntry, error) {
+	min, max := "-inf", "+inf"
+	if !opts.Since.IsZero() {
+		min = strconv.FormatInt(opts.Since.Unix(), 10)
+	}
+	if !opts.Until.IsZero() {
+		max = strconv.FormatInt(opts.Until.Unix(), 10)
+	}
+
+	var matches []redis.SetEntry
+	for offset := int64(0); ; offset += scanBatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		entries, err := store.ScanSet(redis.DeadSet, min, max, offset, scanBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range entries {
+			progress.Scanned++
+			if !f.matches(&entries[i]) {
+				continue
+			}
+			matches = append(matches, entries[i])
+			if opts.Limit > 0 && int64(len(matches)) >= opts.Limit {
+				return matches, nil
+			}
+		}
+
+		// Malformed members are dropped by ScanSet, so only an empty page marks the end
+		if len(entries) == 0 {
+			return matches, nil
+		}
+	}
+}
+
+// Entry replays a single dead entry the way Run does. It returns false if the
+// entry was no longer in the dead set.
+func Entry(store Store, entry *redis.SetEntry) (bool, error) {
+	resetForReplay(entry)
+	return store.RequeueFromSet(redis.DeadSet, entry)
+}
+
+// resetForReplay clears retry state so the job starts over with a full retry budget
+func resetForReplay(entry *redis.SetEntry) {
+	entry.Job.Retry = 0
+	entry.Job.InterruptedCount = 0
+	entry.Job.FailedAt = 0
+	entry.Job.ErrorMsg = ""
+	entry.Job.ErrorClass = ""
+	entry.Job.ErrorBacktrace = nil
+	entry.Job.EnqueuedAt = float64(time.Now().UnixNano()) / 1e9
+}
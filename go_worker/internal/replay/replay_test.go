@@ -0,0 +1,249 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gokiq/internal/job"
+	"gokiq/internal/redis"
+)
+
+// fakeStore serves a fixed dead set and records requeued jobs
+type fakeStore struct {
+	entries  []redis.SetEntry
+	requeued []*job.SidekiqJob
+	gone     map[string]bool
+	failOn   string
+}
+
+func (f *fakeStore) ScanSet(set, min, max string, offset, count int64) ([]redis.SetEntry, error) {
+	if offset >= int64(len(f.entries)) {
+		return nil, nil
+	}
+	end := offset + count
+	if end > int64(len(f.entries)) {
+		end = int64(len(f.entries))
+	}
+	return f.entries[offset:end], nil
+}
+
+func (f *fakeStore) RequeueFromSet(set string, entry *redis.SetEntry) (bool, error) {
+	if entry.Job.JID == f.failOn {
+		return false, errors.New("redis unavailable")
+	}
+	if f.gone[entry.Job.JID] {
+		return false, nil
+	}
+	f.requeued = append(f.requeued, entry.Job)
+	return true, nil
+}
+
+func deadEntry(jid, class, queue, errorClass, errorMsg string) redis.SetEntry {
+	return redis.SetEntry{
+		Score: float64(time.Now().Unix()),
+		Job: &job.SidekiqJob{
+			JID:              jid,
+			Class:            class,
+			Queue:            queue,
+			Retry:            25,
+			InterruptedCount: 2,
+			FailedAt:         float64(time.Now().Unix()),
+			ErrorClass:       errorClass,
+			ErrorMsg:         errorMsg,
+		},
+	}
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		entries: []redis.SetEntry{
+			deadEntry("1", "MailerJob", "default", "Net::ReadTimeout", "execution expired"),
+			deadEntry("2", "MailerJob", "low", "ActiveRecord::RecordNotFound", "Couldn't find User"),
+			deadEntry("3", "BillingJob", "default", "Net::ReadTimeout", "execution expired"),
+			deadEntry("4", "MailerJob", "default", "Net::OpenTimeout", "timed out"),
+		},
+		gone: map[string]bool{},
+	}
+}
+
+func TestRun_Filters(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"class", Options{Class: "MailerJob"}, []string{"1", "2", "4"}},
+		{"queue", Options{Queue: "low"}, []string{"2"}},
+		{"error class regex", Options{ErrorClass: `^Net::`}, []string{"1", "3", "4"}},
+		{"error message regex", Options{ErrorMessage: `expired`}, []string{"1", "3"}},
+		{"combined", Options{Class: "MailerJob", ErrorClass: `Timeout$`}, []string{"1", "4"}},
+		{"limit", Options{Class: "MailerJob", Limit: 2}, []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			progress, err := Run(context.Background(), store, tt.opts, nil)
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+
+			if len(store.requeued) != len(tt.want) {
+				t.Fatalf("Expected %d requeued jobs, got %d", len(tt.want), len(store.requeued))
+			}
+			for i, jid := range tt.want {
+				if store.requeued[i].JID != jid {
+					t.Errorf("Expected requeued JID %s, got %s", jid, store.requeued[i].JID)
+				}
+			}
+
+			if progress.Scanned == 0 || progress.Matched != int64(len(tt.want)) || progress.Replayed != int64(len(tt.want)) {
+				t.Errorf("Unexpected progress: %+v", progress)
+			}
+			if !progress.Done {
+				t.Error("Expected progress to be done")
+			}
+		})
+	}
+}
+
+func TestRun_ResetsRetryState(t *testing.T) {
+	store := newFakeStore()
+	if _, err := Run(context.Background(), store, Options{Class: "BillingJob"}, nil); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	replayed := store.requeued[0]
+	if replayed.Retry != 0 || replayed.InterruptedCount != 0 || replayed.FailedAt != 0 || replayed.ErrorClass != "" || replayed.ErrorMsg != "" {
+		t.Errorf("Expected retry state to be reset, got %+v", replayed)
+	}
+	if replayed.EnqueuedAt == 0 {
+		t.Error("Expected enqueued_at to be set")
+	}
+}
+
+func TestEntry(t *testing.T) {
+	store := newFakeStore()
+	store.gone["2"] = true
+
+	if moved, err := Entry(store, &store.entries[0]); err != nil || !moved {
+		t.Fatalf("Expected the entry to be replayed, got %v, %v", moved, err)
+	}
+	if replayed := store.requeued[0]; replayed.Retry != 0 || replayed.ErrorClass != "" || replayed.EnqueuedAt == 0 {
+		t.Errorf("Expected retry state to be reset, got %+v", replayed)
+	}
+	if moved, err := Entry(store, &store.entries[1]); err != nil || moved {
+		t.Errorf("Expected a vanished entry to be skipped, got %v, %v", moved, err)
+	}
+}
+
+func TestRun_DryRun(t *testing.T) {
+	store := newFakeStore()
+	progress, err := Run(context.Background(), store, Options{Class: "MailerJob", DryRun: true}, nil)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(store.requeued) != 0 {
+		t.Errorf("Dry run should not requeue jobs, got %d", len(store.requeued))
+	}
+	if progress.Matched != 3 || progress.Replayed != 0 {
+		t.Errorf("Unexpected progress: %+v", progress)
+	}
+}
+
+func TestRun_SkippedAndFailed(t *testing.T) {
+	store := newFakeStore()
+	store.gone["1"] = true
+	store.failOn = "3"
+
+	var reports int
+	progress, err := Run(context.Background(), store, Options{}, func(Progress) { reports++ })
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if progress.Replayed != 2 || progress.Skipped != 1 || progress.Failed != 1 {
+		t.Errorf("Unexpected progress: %+v", progress)
+	}
+	// One report after matching, one per job and one final
+	if reports != 6 {
+		t.Errorf("Expected 6 progress reports, got %d", reports)
+	}
+}
+
+func TestRun_Throttled(t *testing.T) {
+	store := newFakeStore()
+
+	start := time.Now()
+	if _, err := Run(context.Background(), store, Options{Rate: 50}, nil); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	// 4 jobs at 50/s wait for 3 ticks of 20ms
+	if elapsed < 50*time.Millisecond {
+		t.Errorf("Expected throttled replay to take at least 50ms, took %v", elapsed)
+	}
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	store := newFakeStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	progress, err := Run(ctx, store, Options{}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(store.requeued) != 0 || !progress.Done {
+		t.Errorf("Unexpected state after cancellation: requeued=%d progress=%+v", len(store.requeued), progress)
+	}
+}
+
+func TestRun_InvalidOptions(t *testing.T) {
+	tests := []Options{
+		{ErrorClass: "("},
+		{ErrorMessage: "["},
+		{Rate: -1},
+		{Since: time.Now(), Until: time.Now().Add(-time.Hour)},
+	}
+
+	for _, opts := range tests {
+		if _, err := Run(context.Background(), newFakeStore(), opts, nil); err == nil {
+			t.Errorf("Expected error for options %+v", opts)
+		}
+	}
+}
+
+func TestManager_RequiresAllWithoutFilter(t *testing.T) {
+	manager := NewManager(newFakeStore())
+
+	if _, err := manager.Start(Options{}); err == nil {
+		t.Error("Expected an unfiltered replay without all to be rejected")
+	}
+	if _, err := manager.Start(Options{All: true}); err != nil {
+		t.Errorf("Expected an explicit replay of everything to start, got %v", err)
+	}
+}
+
+func TestManager_PrunesFinishedReplays(t *testing.T) {
+	manager := NewManager(newFakeStore())
+	for i := 0; i < maxFinished+10; i++ {
+		id := job.NewJID()
+		at := time.Now().Add(time.Duration(i) * time.Second)
+		manager.replays[id] = &tracked{progress: Progress{ID: id, Done: true, StartedAt: at, FinishedAt: at}}
+	}
+	oldest := manager.List()[0].ID
+
+	manager.prune()
+
+	if got := len(manager.List()); got != maxFinished {
+		t.Errorf("Expected %d finished replays to be kept, got %d", maxFinished, got)
+	}
+	if _, ok := manager.Get(oldest); ok {
+		t.Error("Expected the oldest finished replay to be forgotten")
+	}
+}
@@ -0,0 +1,256 @@
+package sidecar
+
+import (
+	"sync"
+	"time"
+
+	"gokiq/internal/config"
+)
+
+// windowBuckets is the number of buckets the failure-rate window is divided into
+const windowBuckets = 10
+
+// CircuitBreaker implements the circuit breaker pattern over a sliding
+// failure-rate window, letting a limited number of probes through while half-open
+type CircuitBreaker struct {
+	failureThreshold int
+	failureRate      float64
+	window           time.Duration
+	resetTimeout     time.Duration
+	halfOpenProbes   int
+
+	buckets        [windowBuckets]bucket
+	state          CircuitState
+	openedAt       time.Time
+	probesInFlight int
+	probeSuccesses int
+	listeners      []func(from, to CircuitState)
+	mu             sync.RWMutex
+}
+
+// bucket counts request outcomes for one slice of the window
+type bucket struct {
+	epoch     int64
+	successes int
+	failures  int
+}
+
+// CircuitState represents the state of the circuit breaker
+type CircuitState int
+
+const (
+	// StateClosed means the circuit is closed and requests are allowed
+	StateClosed CircuitState = iota
+	// StateOpen means the circuit is open and requests are blocked
+	StateOpen
+	// StateHalfOpen means the circuit is testing if the service has recovered
+	StateHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// NewCircuitBreaker creates a circuit breaker that opens after maxFailures
+// failures within a minute and probes with a single request after resetTimeout
+func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration) *CircuitBreaker {
+	return NewCircuitBreakerFromConfig(config.BreakerConfig{
+		FailureThreshold: maxFailures,
+		Window:           time.Minute,
+		ResetTimeout:     resetTimeout,
+		HalfOpenProbes:   1,
+	})
+}
+
+// NewCircuitBreakerFromConfig creates a circuit breaker from configuration
+func NewCircuitBreakerFromConfig(cfg config.BreakerConfig) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		failureThreshold: cfg.FailureThreshold,
+		failureRate:      cfg.FailureRate,
+		window:           cfg.Window,
+		resetTimeout:     cfg.ResetTimeout,
+		halfOpenProbes:   cfg.HalfOpenProbes,
+		state:            StateClosed,
+	}
+	if cb.window < windowBuckets {
+		cb.window = windowBuckets
+	}
+	if cb.halfOpenProbes < 1 {
+		cb.halfOpenProbes = 1
+	}
+	return cb
+}
+
+// OnStateChange registers a callback invoked after every state transition
+func (cb *CircuitBreaker) OnStateChange(listener func(from, to CircuitState)) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.listeners = append(cb.listeners, listener)
+}
+
+// AllowRequest checks if the circuit breaker allows the request. While
+// half-open only the configured number of probes are allowed at once.
+func (cb *CircuitBreaker) AllowRequest() bool {
+	cb.mu.Lock()
+	from := cb.state
+
+	allowed := false
+	switch cb.state {
+	case StateClosed:
+		allowed = true
+	case StateOpen:
+		if time.Since(cb.openedAt) > cb.resetTimeout {
+			cb.setState(StateHalfOpen)
+			cb.probesInFlight = 1
+			allowed = true
+		}
+	case StateHalfOpen:
+		if cb.probesInFlight < cb.halfOpenProbes {
+			cb.probesInFlight++
+			allowed = true
+		}
+	}
+
+	cb.unlockAndNotify(from)
+	return allowed
+}
+
+// Ready reports whether a request would currently be allowed, without
+// reserving a half-open probe
+func (cb *CircuitBreaker) Ready() bool {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	switch cb.state {
+	case StateClosed:
+		return true
+	case StateOpen:
+		return time.Since(cb.openedAt) > cb.resetTimeout
+	default:
+		return cb.probesInFlight < cb.halfOpenProbes
+	}
+}
+
+// RecordSuccess records a successful request
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	from := cb.state
+
+	switch cb.state {
+	case StateHalfOpen:
+		cb.releaseProbe()
+		cb.probeSuccesses++
+		if cb.probeSuccesses >= cb.halfOpenProbes {
+			cb.setState(StateClosed)
+		}
+	case StateClosed:
+		cb.currentBucket(time.Now()).successes++
+	}
+
+	cb.unlockAndNotify(from)
+}
+
+// RecordFailure records a failed request
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	from := cb.state
+	now := time.Now()
+
+	switch cb.state {
+	case StateHalfOpen:
+		cb.releaseProbe()
+		cb.setState(StateOpen)
+		cb.openedAt = now
+	case StateClosed:
+		cb.currentBucket(now).failures++
+		if cb.shouldTrip(now) {
+			cb.setState(StateOpen)
+			cb.openedAt = now
+		}
+	}
+
+	cb.unlockAndNotify(from)
+}
+
+// GetState returns the current state of the circuit breaker
+func (cb *CircuitBreaker) GetState() CircuitState {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.state
+}
+
+// shouldTrip reports whether failures in the window exceed both thresholds
+func (cb *CircuitBreaker) shouldTrip(now time.Time) bool {
+	successes, failures := cb.totals(now)
+	if failures < cb.failureThreshold {
+		return false
+	}
+	return float64(failures)/float64(successes+failures) >= cb.failureRate
+}
+
+// totals sums the outcomes recorded within the window ending at now
+func (cb *CircuitBreaker) totals(now time.Time) (successes, failures int) {
+	current := cb.epoch(now)
+	for _, b := range cb.buckets {
+		if b.epoch > current-windowBuckets && b.epoch <= current {
+			successes += b.successes
+			failures += b.failures
+		}
+	}
+	return successes, failures
+}
+
+func (cb *CircuitBreaker) currentBucket(now time.Time) *bucket {
+	epoch := cb.epoch(now)
+	b := &cb.buckets[epoch%windowBuckets]
+	if b.epoch != epoch {
+		*b = bucket{epoch: epoch}
+	}
+	return b
+}
+
+func (cb *CircuitBreaker) epoch(now time.Time) int64 {
+	return now.UnixNano() / int64(cb.window/windowBuckets)
+}
+
+func (cb *CircuitBreaker) releaseProbe() {
+	if cb.probesInFlight > 0 {
+		cb.probesInFlight--
+	}
+}
+
+// setState moves to a new state, resetting the bookkeeping of the old one
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	cb.state = state
+	cb.probeSuccesses = 0
+	if state != StateHalfOpen {
+		cb.probesInFlight = 0
+	}
+	if state == StateClosed {
+		cb.buckets = [windowBuckets]bucket{}
+	}
+}
+
+// unlockAndNotify releases the lock and, if the state changed since from,
+// invokes the registered listeners
+func (cb *CircuitBreaker) unlockAndNotify(from CircuitState) {
+	to := cb.state
+	listeners := cb.listeners
+	cb.mu.Unlock()
+
+	if from == to {
+		return
+	}
+	for _, listener := range listeners {
+		listener(from, to)
+	}
+}
@@ -0,0 +1,124 @@
+package sidecar
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gokiq/internal/config"
+)
+
+func testBreakerConfig() config.BreakerConfig {
+	return config.BreakerConfig{
+		FailureThreshold: 3,
+		FailureRate:      0.5,
+		Window:           time.Minute,
+		ResetTimeout:     50 * time.Millisecond,
+		HalfOpenProbes:   2,
+	}
+}
+
+func TestCircuitBreaker_FailureRate(t *testing.T) {
+	breaker := NewCircuitBreakerFromConfig(testBreakerConfig())
+
+	// 3 failures out of 10 requests is below the 50% rate
+	for i := 0; i < 7; i++ {
+		breaker.RecordSuccess()
+	}
+	for i := 0; i < 3; i++ {
+		breaker.RecordFailure()
+	}
+	if breaker.GetState() != StateClosed {
+		t.Fatalf("Expected breaker to stay closed at 30%% failures, got %v", breaker.GetState())
+	}
+
+	// 7 failures out of 14 reaches the rate
+	for i := 0; i < 4; i++ {
+		breaker.RecordFailure()
+	}
+	if breaker.GetState() != StateOpen {
+		t.Errorf("Expected breaker to open at 50%% failures, got %v", breaker.GetState())
+	}
+}
+
+func TestCircuitBreaker_WindowExpires(t *testing.T) {
+	cfg := testBreakerConfig()
+	cfg.Window = 100 * time.Millisecond
+	breaker := NewCircuitBreakerFromConfig(cfg)
+
+	breaker.RecordFailure()
+	breaker.RecordFailure()
+	time.Sleep(150 * time.Millisecond)
+
+	// Old failures have left the window, so a third does not trip the breaker
+	breaker.RecordFailure()
+	if breaker.GetState() != StateClosed {
+		t.Errorf("Expected failures outside the window to be forgotten, got %v", breaker.GetState())
+	}
+}
+
+func TestCircuitBreaker_LimitsHalfOpenProbes(t *testing.T) {
+	breaker := NewCircuitBreakerFromConfig(testBreakerConfig())
+	for i := 0; i < 3; i++ {
+		breaker.RecordFailure()
+	}
+	if breaker.Ready() {
+		t.Error("Open breaker should not be ready")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !breaker.Ready() {
+		t.Error("Breaker should be ready once the reset timeout has passed")
+	}
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if breaker.AllowRequest() {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Fatalf("Expected 2 half-open probes, got %d", allowed)
+	}
+	if breaker.Ready() {
+		t.Error("Breaker should not be ready while all probes are in flight")
+	}
+
+	// One success is not enough to close with two probes
+	breaker.RecordSuccess()
+	if breaker.GetState() != StateHalfOpen {
+		t.Fatalf("Expected half-open after first probe success, got %v", breaker.GetState())
+	}
+	breaker.RecordSuccess()
+	if breaker.GetState() != StateClosed {
+		t.Errorf("Expected closed after all probes succeeded, got %v", breaker.GetState())
+	}
+}
+
+func TestCircuitBreaker_ProbeFailureReopens(t *testing.T) {
+	breaker := NewCircuitBreakerFromConfig(testBreakerConfig())
+
+	var transitions []string
+	breaker.OnStateChange(func(from, to CircuitState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+
+	for i := 0; i < 3; i++ {
+		breaker.RecordFailure()
+	}
+	time.Sleep(60 * time.Millisecond)
+	breaker.AllowRequest()
+	breaker.RecordFailure()
+
+	if breaker.GetState() != StateOpen {
+		t.Errorf("Expected probe failure to reopen the breaker, got %v", breaker.GetState())
+	}
+	if breaker.AllowRequest() {
+		t.Error("Reopened breaker should wait for another reset timeout")
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open"}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Errorf("Transitions = %v, want %v", transitions, want)
+	}
+}
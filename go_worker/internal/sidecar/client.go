@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"sync"
 	"time"
@@ -14,75 +15,96 @@ import (
 	"gokiq/internal/job"
 )
 
+// outcomeTTL is how long a recorded final sidecar result prevents re-execution
+const outcomeTTL = 24 * time.Hour
+
+// IdempotencyHeader carries the idempotency key of an execute request
+const IdempotencyHeader = "Idempotency-Key"
+
 // HTTPClient implements the SidecarClient interface using HTTP
 type HTTPClient struct {
-	baseURL    string
-	httpClient *http.Client
-	breaker    *CircuitBreaker
-	mu         sync.RWMutex
-}
-
-// CircuitBreaker implements a simple circuit breaker pattern
-type CircuitBreaker struct {
-	maxFailures  int
-	resetTimeout time.Duration
-	failures     int
-	lastFailTime time.Time
-	state        CircuitState
+	baseURL      string
+	httpClient   *http.Client
+	pool         *poolTracker
+	breaker      *CircuitBreaker
+	timeout      time.Duration
+	retries      int
+	classRetries map[string]int
+	outcomes     OutcomeStore
 	mu           sync.RWMutex
-}
-
-// CircuitState represents the state of the circuit breaker
-type CircuitState int
 
-const (
-	// StateClosed means the circuit is closed and requests are allowed
-	StateClosed CircuitState = iota
-	// StateOpen means the circuit is open and requests are blocked
-	StateOpen
-	// StateHalfOpen means the circuit is testing if the service has recovered
-	StateHalfOpen
-)
+	// redactBodies keeps response bodies out of errors, which are logged and
+	// stored in the job payload
+	redactBodies bool
+}
 
 // NewClient creates a new SidecarClient based on configuration
 func NewClient(cfg config.SidecarConfig) SidecarClient {
-	return NewHTTPClient(cfg.URL, cfg.Timeout)
+	return NewHTTPClientFromConfig(cfg)
 }
 
-// NewHTTPClient creates a new HTTP client for sidecar communication
+// NewHTTPClient creates a new HTTP client for sidecar communication using the
+// default circuit breaker settings
 func NewHTTPClient(baseURL string, timeout time.Duration) *HTTPClient {
-	// Create a custom transport to handle high concurrency
-	transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 100,
-		MaxConnsPerHost:     100,
-		IdleConnTimeout:     90 * time.Second,
-	}
+	return NewHTTPClientFromConfig(config.SidecarConfig{
+		URL:     baseURL,
+		Timeout: timeout,
+		Breaker: config.Default().Sidecar.Breaker,
+		Retries: config.Default().Sidecar.Retries,
+		Pool:    config.Default().Sidecar.Pool,
+	})
+}
 
+// NewHTTPClientFromConfig creates a new HTTP client for sidecar communication
+func NewHTTPClientFromConfig(cfg config.SidecarConfig) *HTTPClient {
+	// Pool usage is tracked so saturation is visible rather than silent queueing
+	pool := newTransport(cfg.Pool)
+
+	// Execute requests are bounded per attempt by the adjustable timeout rather than http.Client.Timeout
 	return &HTTPClient{
-		baseURL: baseURL,
+		baseURL: cfg.URL,
 		httpClient: &http.Client{
-			Timeout:   timeout,
-			Transport: transport,
+			Transport: pool,
 		},
-		breaker: NewCircuitBreaker(10, 30*time.Second), // Increased failure threshold
+		pool:         pool,
+		breaker:      NewCircuitBreakerFromConfig(cfg.Breaker),
+		timeout:      cfg.Timeout,
+		retries:      cfg.Retries,
+		classRetries: cfg.ClassRetries,
 	}
 }
 
-// NewCircuitBreaker creates a new circuit breaker
-func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration) *CircuitBreaker {
-	return &CircuitBreaker{
-		maxFailures:  maxFailures,
-		resetTimeout: resetTimeout,
-		state:        StateClosed,
-	}
+// IdempotencyKey identifies one execution attempt of a job. Transport retries
+// of the same attempt share the key; a Sidekiq retry gets a new one.
+func IdempotencyKey(jobData *job.SidekiqJob) string {
+	return fmt.Sprintf("%s-%d", jobData.JID, jobData.Retry)
+}
+
+// SetOutcomeStore enables recording results by idempotency key, so an attempt
+// whose result was already received is not sent to the sidecar again
+func (c *HTTPClient) SetOutcomeStore(store OutcomeStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.outcomes = store
 }
 
 // ExecuteJob sends a job to the Rails sidecar for execution
 func (c *HTTPClient) ExecuteJob(jobData *job.SidekiqJob) (*job.JobResult, error) {
-	// Check circuit breaker
-	if !c.breaker.AllowRequest() {
-		return nil, fmt.Errorf("circuit breaker is open")
+	key := IdempotencyKey(jobData)
+
+	c.mu.RLock()
+	outcomes := c.outcomes
+	c.mu.RUnlock()
+
+	if outcomes != nil {
+		recorded, err := outcomes.JobOutcome(key)
+		if err != nil {
+			log.Printf("Failed to look up job outcome, executing anyway: JID=%s, Error=%v", jobData.JID, err)
+		} else if recorded != nil {
+			log.Printf("Job attempt already completed, skipping execution: JID=%s, Class=%s, Key=%s",
+				jobData.JID, jobData.Class, key)
+			return recorded, nil
+		}
 	}
 
 	// Prepare request payload
@@ -100,10 +122,17 @@ func (c *HTTPClient) ExecuteJob(jobData *job.SidekiqJob) (*job.JobResult, error)
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set(IdempotencyHeader, key)
+
+	// Check circuit breaker last, as a half-open probe it reserves is only
+	// released by recording the request's outcome
+	if !c.breaker.AllowRequest() {
+		return nil, fmt.Errorf("circuit breaker is open")
+	}
 
 	// Execute request with retry logic
 	result := &job.JobResult{}
-	err = c.executeWithRetry(req, result, 3)
+	err = c.executeWithRetry(req, result, c.retriesFor(jobData.Class))
 
 	if err != nil {
 		c.breaker.RecordFailure()
@@ -111,9 +140,78 @@ func (c *HTTPClient) ExecuteJob(jobData *job.SidekiqJob) (*job.JobResult, error)
 	}
 
 	c.breaker.RecordSuccess()
+
+	// Failures are not recorded, so retrying the same attempt from the dead
+	// set or replaying it executes the job again
+	if outcomes != nil && (result.Status == "success" || result.Discard) {
+		if err := outcomes.RecordJobOutcome(key, result, outcomeTTL); err != nil {
+			log.Printf("Failed to record job outcome: JID=%s, Error=%v", jobData.JID, err)
+		}
+	}
 	return result, nil
 }
 
+// RedactErrorBodies leaves response bodies out of execute errors, as the
+// sidecar may echo decrypted args in them
+func (c *HTTPClient) RedactErrorBodies() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.redactBodies = true
+}
+
+// errorBody returns a response body for an error message
+func (c *HTTPClient) errorBody(body []byte) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.redactBodies {
+		return fmt.Sprintf("[%d bytes redacted]", len(body))
+	}
+	return string(body)
+}
+
+// retriesFor returns the number of transport retries for a job class
+func (c *HTTPClient) retriesFor(class string) int {
+	if retries, ok := c.classRetries[class]; ok {
+		return retries
+	}
+	return c.retries
+}
+
+// Ready reports whether the circuit breaker would let a job through, so
+// callers can stop fetching work while the sidecar is unavailable
+func (c *HTTPClient) Ready() bool {
+	return c.breaker.Ready()
+}
+
+// PoolStats returns the usage of the connection pool to the sidecar
+func (c *HTTPClient) PoolStats() PoolStats {
+	return c.pool.Stats()
+}
+
+// OnBreakerStateChange registers a callback for circuit breaker transitions
+func (c *HTTPClient) OnBreakerStateChange(listener func(from, to CircuitState)) {
+	c.breaker.OnStateChange(listener)
+}
+
+// SetPool changes the connection pool limits for subsequent requests
+func (c *HTTPClient) SetPool(cfg config.SidecarPoolConfig) {
+	c.pool.resize(cfg)
+}
+
+// SetTimeout changes the per-attempt timeout for subsequent job executions
+func (c *HTTPClient) SetTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = timeout
+}
+
+// Timeout returns the per-attempt timeout for job executions
+func (c *HTTPClient) Timeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.timeout
+}
+
 // HealthCheck performs a health check on the Rails sidecar
 func (c *HTTPClient) HealthCheck() error {
 	url := fmt.Sprintf("%s/health", c.baseURL)
@@ -166,12 +264,14 @@ func (c *HTTPClient) executeWithRetry(req *http.Request, result interface{}, max
 		}
 
 		// Clone request for retry (body needs to be reset)
-		reqClone := req.Clone(req.Context())
+		ctx, cancel := context.WithTimeout(req.Context(), c.Timeout())
+		reqClone := req.Clone(ctx)
 		if req.Body != nil {
 			// Reset body for retry
 			if req.GetBody != nil {
 				body, err := req.GetBody()
 				if err != nil {
+					cancel()
 					return fmt.Errorf("failed to get request body for retry: %w", err)
 				}
 				reqClone.Body = body
@@ -180,6 +280,7 @@ func (c *HTTPClient) executeWithRetry(req *http.Request, result interface{}, max
 
 		resp, err := c.httpClient.Do(reqClone)
 		if err != nil {
+			cancel()
 			lastErr = fmt.Errorf("request failed (attempt %d): %w", attempt+1, err)
 			continue
 		}
@@ -187,21 +288,23 @@ func (c *HTTPClient) executeWithRetry(req *http.Request, result interface{}, max
 		// Read response body
 		body, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
+		cancel()
 
 		if err != nil {
 			lastErr = fmt.Errorf("failed to read response body (attempt %d): %w", attempt+1, err)
 			continue
 		}
 
-		// Check status code
-		if resp.StatusCode >= 500 {
+		// Check status code. A conflict means the sidecar is still running this
+		// attempt for an earlier request, so try again once it may have finished.
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusConflict {
 			lastErr = fmt.Errorf("server error (attempt %d): status %d, body: %s",
-				attempt+1, resp.StatusCode, string(body))
+				attempt+1, resp.StatusCode, c.errorBody(body))
 			continue
 		}
 
 		if resp.StatusCode >= 400 {
-			return fmt.Errorf("client error: status %d, body: %s", resp.StatusCode, string(body))
+			return fmt.Errorf("client error: status %d, body: %s", resp.StatusCode, c.errorBody(body))
 		}
 
 		// Parse successful response
@@ -216,53 +319,3 @@ func (c *HTTPClient) executeWithRetry(req *http.Request, result interface{}, max
 
 	return fmt.Errorf("request failed after %d attempts: %w", maxRetries+1, lastErr)
 }
-
-// AllowRequest checks if the circuit breaker allows the request
-func (cb *CircuitBreaker) AllowRequest() bool {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
-
-	switch cb.state {
-	case StateClosed:
-		return true
-	case StateOpen:
-		if time.Since(cb.lastFailTime) > cb.resetTimeout {
-			cb.state = StateHalfOpen
-			return true
-		}
-		return false
-	case StateHalfOpen:
-		return true
-	default:
-		return false
-	}
-}
-
-// RecordSuccess records a successful request
-func (cb *CircuitBreaker) RecordSuccess() {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
-
-	cb.failures = 0
-	cb.state = StateClosed
-}
-
-// RecordFailure records a failed request
-func (cb *CircuitBreaker) RecordFailure() {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
-
-	cb.failures++
-	cb.lastFailTime = time.Now()
-
-	if cb.failures >= cb.maxFailures {
-		cb.state = StateOpen
-	}
-}
-
-// GetState returns the current state of the circuit breaker
-func (cb *CircuitBreaker) GetState() CircuitState {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	return cb.state
-}
@@ -4,17 +4,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"gokiq/internal/config"
 	"gokiq/internal/job"
 )
 
 func TestHTTPClient_ExecuteJob_Success(t *testing.T) {
 	// Create mock server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/jobs/execute" {
-			t.Errorf("Expected path /jobs/execute, got %s", r.URL.Path)
+		if r.URL.Path != "/execute" {
+			t.Errorf("Expected path /execute, got %s", r.URL.Path)
 		}
 		if r.Method != "POST" {
 			t.Errorf("Expected POST method, got %s", r.Method)
@@ -201,6 +203,25 @@ func TestHTTPClient_ExecuteJob_ClientError_NoRetry(t *testing.T) {
 	}
 }
 
+func TestHTTPClient_ExecuteJob_RedactsErrorBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error":"invalid email alice@example.com"}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, 5*time.Second)
+	client.RedactErrorBodies()
+
+	_, err := client.ExecuteJob(&job.SidekiqJob{Class: "NotifyJob", JID: "jid-1"})
+	if err == nil {
+		t.Fatal("Expected error but got nil")
+	}
+	if strings.Contains(err.Error(), "alice") || !strings.Contains(err.Error(), "status 422") {
+		t.Errorf("Expected the status without the body, got %v", err)
+	}
+}
+
 func TestHTTPClient_HealthCheck_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/health" {
@@ -379,3 +400,166 @@ func TestHTTPClient_Timeout(t *testing.T) {
 		t.Fatal("Expected timeout error but got nil")
 	}
 }
+
+// fakeOutcomeStore is an in-memory OutcomeStore
+type fakeOutcomeStore struct {
+	outcomes map[string]*job.JobResult
+}
+
+func (f *fakeOutcomeStore) JobOutcome(key string) (*job.JobResult, error) {
+	return f.outcomes[key], nil
+}
+
+func (f *fakeOutcomeStore) RecordJobOutcome(key string, result *job.JobResult, ttl time.Duration) error {
+	f.outcomes[key] = result
+	return nil
+}
+
+func TestHTTPClient_ExecuteJob_IdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyHeader))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(job.JobResult{Status: "success"})
+	}))
+	defer server.Close()
+
+	store := &fakeOutcomeStore{outcomes: map[string]*job.JobResult{}}
+	client := NewHTTPClient(server.URL, 5*time.Second)
+	client.SetOutcomeStore(store)
+
+	testJob := &job.SidekiqJob{Class: "TestJob", JID: "jid-1", Retry: 2}
+	if _, err := client.ExecuteJob(testJob); err != nil {
+		t.Fatalf("ExecuteJob failed: %v", err)
+	}
+
+	if len(keys) != 2 || keys[0] != "jid-1-2" || keys[1] != "jid-1-2" {
+		t.Errorf("Expected both attempts to carry key jid-1-2, got %v", keys)
+	}
+	if store.outcomes["jid-1-2"] == nil {
+		t.Fatal("Expected outcome to be recorded")
+	}
+
+	// The same attempt is not sent again once its outcome is known
+	if _, err := client.ExecuteJob(testJob); err != nil {
+		t.Fatalf("ExecuteJob failed: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected recorded outcome to be reused, got %d requests", len(keys))
+	}
+
+	// A Sidekiq retry is a new attempt
+	testJob.Retry = 3
+	client.ExecuteJob(testJob)
+	if len(keys) != 3 || keys[2] != "jid-1-3" {
+		t.Errorf("Expected new attempt with key jid-1-3, got %v", keys)
+	}
+}
+
+func TestHTTPClient_ExecuteJob_RecordsOnlyFinalOutcomes(t *testing.T) {
+	results := map[string]job.JobResult{
+		"failed":    {Status: "failure", ErrorClass: "RuntimeError"},
+		"discarded": {Status: "failure", ErrorClass: "RuntimeError", Discard: true},
+		"succeeded": {Status: "success"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload job.SidekiqJob
+		json.NewDecoder(r.Body).Decode(&payload)
+		json.NewEncoder(w).Encode(results[payload.JID])
+	}))
+	defer server.Close()
+
+	store := &fakeOutcomeStore{outcomes: map[string]*job.JobResult{}}
+	client := NewHTTPClient(server.URL, 5*time.Second)
+	client.SetOutcomeStore(store)
+
+	for jid := range results {
+		if _, err := client.ExecuteJob(&job.SidekiqJob{Class: "TestJob", JID: jid}); err != nil {
+			t.Fatalf("ExecuteJob failed: %v", err)
+		}
+	}
+
+	if store.outcomes["failed-0"] != nil {
+		t.Error("Expected a failed attempt not to be recorded")
+	}
+	if store.outcomes["discarded-0"] == nil || store.outcomes["succeeded-0"] == nil {
+		t.Errorf("Expected discarded and successful attempts to be recorded, got %v", store.outcomes)
+	}
+}
+
+func TestHTTPClient_ExecuteJob_RetriesAttemptInProgress(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		json.NewEncoder(w).Encode(job.JobResult{Status: "success"})
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, 5*time.Second)
+	result, err := client.ExecuteJob(&job.SidekiqJob{Class: "TestJob", JID: "jid-1"})
+	if err != nil {
+		t.Fatalf("ExecuteJob failed: %v", err)
+	}
+	if requests != 2 || result.Status != "success" {
+		t.Errorf("Expected the attempt in progress to be retried, got %d requests and %+v", requests, result)
+	}
+}
+
+func TestHTTPClient_ExecuteJob_ClassRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClientFromConfig(config.SidecarConfig{
+		URL:          server.URL,
+		Timeout:      time.Second,
+		Breaker:      config.Default().Sidecar.Breaker,
+		Retries:      1,
+		ClassRetries: map[string]int{"ChargeCardJob": 0},
+	})
+
+	client.ExecuteJob(&job.SidekiqJob{Class: "ChargeCardJob", JID: "jid-1"})
+	if requests != 1 {
+		t.Errorf("Expected transport retries to be off for ChargeCardJob, got %d requests", requests)
+	}
+
+	requests = 0
+	client.ExecuteJob(&job.SidekiqJob{Class: "OtherJob", JID: "jid-2"})
+	if requests != 2 {
+		t.Errorf("Expected 1 transport retry for OtherJob, got %d requests", requests)
+	}
+}
+
+func TestHTTPClient_ExecuteJob_UnsentRequestKeepsProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(job.JobResult{Status: "success"})
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, 5*time.Second)
+	client.breaker = NewCircuitBreaker(1, 10*time.Millisecond)
+	client.breaker.RecordFailure()
+	time.Sleep(20 * time.Millisecond)
+
+	// Args that cannot be marshaled never reach the sidecar
+	if _, err := client.ExecuteJob(&job.SidekiqJob{Class: "TestJob", JID: "bad", Args: []interface{}{make(chan int)}}); err == nil {
+		t.Fatal("Expected a marshal error")
+	}
+
+	if _, err := client.ExecuteJob(&job.SidekiqJob{Class: "TestJob", JID: "probe"}); err != nil {
+		t.Fatalf("Expected the half-open probe to still be available, got %v", err)
+	}
+	if client.breaker.GetState() != StateClosed {
+		t.Errorf("Expected the successful probe to close the breaker, got %v", client.breaker.GetState())
+	}
+}
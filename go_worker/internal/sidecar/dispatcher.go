@@ -0,0 +1,171 @@
+package sidecar
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"gokiq/internal/config"
+	"gokiq/internal/job"
+)
+
+// DefaultExecutor names the client built from the sidecar section, which
+// receives every job no route matches
+const DefaultExecutor = "sidecar"
+
+// Dispatcher sends each job to the sidecar client chosen by the configured
+// routes, so one worker can serve several Rails applications. Every client
+// has its own URL, timeout, circuit breaker and transport.
+type Dispatcher struct {
+	clients map[string]*HTTPClient
+	routes  []config.RouteConfig
+}
+
+// NewDispatcher creates a dispatcher over the default client and the named
+// executors. Routes must only name executors that are present.
+func NewDispatcher(defaultClient *HTTPClient, executors map[string]*HTTPClient, routes []config.RouteConfig) *Dispatcher {
+	clients := make(map[string]*HTTPClient, len(executors)+1)
+	for name, client := range executors {
+		clients[name] = client
+	}
+	clients[DefaultExecutor] = defaultClient
+
+	return &Dispatcher{
+		clients: clients,
+		routes:  routes,
+	}
+}
+
+// NewDispatcherFromConfig creates a client for the sidecar section and for
+// every configured executor
+func NewDispatcherFromConfig(cfg *config.Config) *Dispatcher {
+	executors := make(map[string]*HTTPClient, len(cfg.Executors))
+	for name, executor := range cfg.Executors {
+		executors[name] = NewHTTPClientFromConfig(executor)
+	}
+	return NewDispatcher(NewHTTPClientFromConfig(cfg.Sidecar), executors, cfg.Routes)
+}
+
+// ExecuteJob sends the job to the client its route selects
+func (d *Dispatcher) ExecuteJob(jobData *job.SidekiqJob) (*job.JobResult, error) {
+	return d.clients[d.Route(jobData)].ExecuteJob(jobData)
+}
+
+// Route returns the name of the executor for a job: the first route whose
+// class patterns and queues both match, or DefaultExecutor
+func (d *Dispatcher) Route(jobData *job.SidekiqJob) string {
+	for _, route := range d.routes {
+		if matchesClass(route.Classes, jobData.Class) && matchesQueue(route.Queues, jobData.Queue) {
+			return route.Executor
+		}
+	}
+	return DefaultExecutor
+}
+
+// Client returns the client for a named executor
+func (d *Dispatcher) Client(name string) (*HTTPClient, bool) {
+	client, ok := d.clients[name]
+	return client, ok
+}
+
+// Names returns every executor name, sorted
+func (d *Dispatcher) Names() []string {
+	names := make([]string, 0, len(d.clients))
+	for name := range d.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Ready reports whether any executor would accept a request
+func (d *Dispatcher) Ready() bool {
+	for _, client := range d.clients {
+		if client.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadyFor reports whether the executor a job routes to would accept it
+func (d *Dispatcher) ReadyFor(jobData *job.SidekiqJob) bool {
+	return d.clients[d.Route(jobData)].Ready()
+}
+
+// ReadyQueues returns the queues, in order, with a job that some ready
+// executor could take. A queue whose routes all lead to executors with an
+// open breaker is left in Redis.
+func (d *Dispatcher) ReadyQueues(queues []string) []string {
+	ready := make([]string, 0, len(queues))
+	for _, queue := range queues {
+		for _, name := range d.queueExecutors(queue) {
+			if d.clients[name].Ready() {
+				ready = append(ready, queue)
+				break
+			}
+		}
+	}
+	return ready
+}
+
+// queueExecutors returns every executor a job from queue may route to
+func (d *Dispatcher) queueExecutors(queue string) []string {
+	var names []string
+	for _, route := range d.routes {
+		if !matchesQueue(route.Queues, queue) {
+			continue
+		}
+		names = append(names, route.Executor)
+		if len(route.Classes) == 0 {
+			// Catches every remaining job of the queue
+			return names
+		}
+	}
+	return append(names, DefaultExecutor)
+}
+
+// PoolStats returns the connection pool usage of every executor by name
+func (d *Dispatcher) PoolStats() map[string]PoolStats {
+	stats := make(map[string]PoolStats, len(d.clients))
+	for name, client := range d.clients {
+		stats[name] = client.PoolStats()
+	}
+	return stats
+}
+
+// HealthCheck checks every executor, reporting the first that is unhealthy
+func (d *Dispatcher) HealthCheck() error {
+	for _, name := range d.Names() {
+		if err := d.clients[name].HealthCheck(); err != nil {
+			return fmt.Errorf("executor %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// matchesClass reports whether class matches any pattern; no patterns match every class
+func matchesClass(patterns []string, class string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, class); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesQueue reports whether queue is listed; no queues match every queue
+func matchesQueue(queues []string, queue string) bool {
+	if len(queues) == 0 {
+		return true
+	}
+	for _, q := range queues {
+		if q == queue {
+			return true
+		}
+	}
+	return false
+}
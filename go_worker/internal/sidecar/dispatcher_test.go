@@ -0,0 +1,123 @@
+package sidecar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"gokiq/internal/config"
+	"gokiq/internal/job"
+)
+
+// namedServer answers every execute request with its name as the result
+func namedServer(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(job.JobResult{Status: "success", Result: name})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDispatcher_Route(t *testing.T) {
+	dispatcher := NewDispatcher(NewHTTPClient("http://rails", time.Second), map[string]*HTTPClient{
+		"billing": NewHTTPClient("http://billing", time.Second),
+		"search":  NewHTTPClient("http://search", time.Second),
+	}, []config.RouteConfig{
+		{Executor: "billing", Classes: []string{"Billing::*"}},
+		{Executor: "search", Queues: []string{"search", "reindex"}},
+		{Executor: "billing", Classes: []string{"*Invoice*"}, Queues: []string{"mailers"}},
+	})
+
+	tests := []struct {
+		class, queue, executor string
+	}{
+		{"Billing::ChargeJob", "default", "billing"},
+		{"Billing::Nested::RefundJob", "search", "billing"},
+		{"ReindexJob", "reindex", "search"},
+		{"SendInvoiceJob", "mailers", "billing"},
+		{"SendInvoiceJob", "default", DefaultExecutor},
+		{"WelcomeMailer", "mailers", DefaultExecutor},
+	}
+
+	for _, tt := range tests {
+		got := dispatcher.Route(&job.SidekiqJob{Class: tt.class, Queue: tt.queue})
+		if got != tt.executor {
+			t.Errorf("Route(%s on %s) = %s, expected %s", tt.class, tt.queue, got, tt.executor)
+		}
+	}
+}
+
+func TestDispatcher_ExecuteJob(t *testing.T) {
+	cfg := config.Default()
+	cfg.Sidecar.URL = namedServer(t, "rails").URL
+	billing := cfg.Sidecar
+	billing.URL = namedServer(t, "billing").URL
+	cfg.Executors = map[string]config.SidecarConfig{"billing": billing}
+	cfg.Routes = []config.RouteConfig{{Executor: "billing", Classes: []string{"Billing::*"}}}
+
+	dispatcher := NewDispatcherFromConfig(cfg)
+
+	result, err := dispatcher.ExecuteJob(&job.SidekiqJob{JID: "a", Class: "Billing::ChargeJob", Queue: "default"})
+	if err != nil || result.Result != "billing" {
+		t.Errorf("Expected billing executor, got %+v, %v", result, err)
+	}
+	result, err = dispatcher.ExecuteJob(&job.SidekiqJob{JID: "b", Class: "HardWorkJob", Queue: "default"})
+	if err != nil || result.Result != "rails" {
+		t.Errorf("Expected default sidecar, got %+v, %v", result, err)
+	}
+
+	if names := dispatcher.Names(); len(names) != 2 || names[0] != "billing" || names[1] != DefaultExecutor {
+		t.Errorf("Expected executors [billing sidecar], got %v", names)
+	}
+}
+
+func TestDispatcher_Ready(t *testing.T) {
+	rails := NewHTTPClient("http://rails", time.Second)
+	billing := NewHTTPClient("http://billing", time.Second)
+	dispatcher := NewDispatcher(rails, map[string]*HTTPClient{"billing": billing}, nil)
+
+	for i := 0; i < 20; i++ {
+		billing.breaker.RecordFailure()
+	}
+	if !dispatcher.Ready() {
+		t.Error("Expected dispatcher to be ready while one executor is healthy")
+	}
+
+	for i := 0; i < 20; i++ {
+		rails.breaker.RecordFailure()
+	}
+	if dispatcher.Ready() {
+		t.Error("Expected dispatcher not to be ready when every breaker is open")
+	}
+}
+
+func TestDispatcher_ReadyQueues(t *testing.T) {
+	rails := NewHTTPClient("http://rails", time.Second)
+	billing := NewHTTPClient("http://billing", time.Second)
+	dispatcher := NewDispatcher(rails, map[string]*HTTPClient{"billing": billing}, []config.RouteConfig{
+		{Executor: "billing", Queues: []string{"billing"}},
+		{Executor: "billing", Classes: []string{"Billing::*"}},
+	})
+
+	for i := 0; i < 20; i++ {
+		billing.breaker.RecordFailure()
+	}
+
+	// The billing queue only reaches billing; default may hold jobs for either
+	queues := dispatcher.ReadyQueues([]string{"billing", "default"})
+	if !reflect.DeepEqual(queues, []string{"default"}) {
+		t.Errorf("Expected only the default queue to be polled, got %v", queues)
+	}
+
+	if dispatcher.ReadyFor(&job.SidekiqJob{Class: "Billing::Invoice", Queue: "default"}) {
+		t.Error("Expected a job routed to billing not to be ready")
+	}
+	if !dispatcher.ReadyFor(&job.SidekiqJob{Class: "Mailer", Queue: "default"}) {
+		t.Error("Expected a job routed to rails to be ready")
+	}
+}
@@ -1,6 +1,10 @@
 package sidecar
 
-import "gokiq/internal/job"
+import (
+	"time"
+
+	"gokiq/internal/job"
+)
 
 // SidecarClient defines the interface for communicating with the Rails sidecar
 type SidecarClient interface {
@@ -10,3 +14,13 @@ type SidecarClient interface {
 	// HealthCheck performs a health check on the Rails sidecar
 	HealthCheck() error
 }
+
+// OutcomeStore records sidecar results by idempotency key so a job attempt
+// that already completed is not executed again
+type OutcomeStore interface {
+	// JobOutcome returns the recorded result for a key, or nil if there is none
+	JobOutcome(idempotencyKey string) (*job.JobResult, error)
+
+	// RecordJobOutcome stores the result for a key for ttl
+	RecordJobOutcome(idempotencyKey string, result *job.JobResult, ttl time.Duration) error
+}
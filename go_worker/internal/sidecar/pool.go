@@ -0,0 +1,115 @@
+package sidecar
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gokiq/internal/config"
+)
+
+// PoolStats describes a sidecar connection pool. Active requests hold or are
+// waiting for a connection until their response body is closed; Saturated
+// counts requests that started while all MaxConns connections were taken and
+// WaitSeconds is the total time requests spent waiting for a connection.
+type PoolStats struct {
+	MaxConns    int     `json:"max_conns"`
+	Active      int64   `json:"active"`
+	Waiting     int64   `json:"waiting"`
+	Saturated   int64   `json:"saturated"`
+	WaitSeconds float64 `json:"wait_seconds"`
+}
+
+// poolTracker is a RoundTripper that records connection pool usage. Its
+// transport can be replaced to change the pool limits while requests run.
+type poolTracker struct {
+	transport atomic.Pointer[http.Transport]
+	maxConns  atomic.Int64
+
+	active    atomic.Int64
+	waiting   atomic.Int64
+	saturated atomic.Int64
+	waitNanos atomic.Int64
+}
+
+// newTransport builds the pooled transport for a sidecar, wrapped to track usage
+func newTransport(cfg config.SidecarPoolConfig) *poolTracker {
+	p := &poolTracker{}
+	p.resize(cfg)
+	return p
+}
+
+// resize switches new requests to a transport with the limits in cfg.
+// Requests already sent finish on the old transport, whose idle connections
+// are closed.
+func (p *poolTracker) resize(cfg config.SidecarPoolConfig) {
+	transport := &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConns,
+		MaxConnsPerHost:     cfg.MaxConns,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+	}
+	p.maxConns.Store(int64(cfg.MaxConns))
+	if old := p.transport.Swap(transport); old != nil {
+		old.CloseIdleConnections()
+	}
+}
+
+// RoundTrip sends the request, counting it as active until its body is closed
+func (p *poolTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	if active, maxConns := p.active.Add(1), p.maxConns.Load(); maxConns > 0 && active > maxConns {
+		p.saturated.Add(1)
+	}
+	p.waiting.Add(1)
+
+	start := time.Now()
+	var gotConn sync.Once
+	connected := func() {
+		gotConn.Do(func() {
+			p.waiting.Add(-1)
+			p.waitNanos.Add(int64(time.Since(start)))
+		})
+	}
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { connected() },
+	}
+
+	resp, err := p.transport.Load().RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	connected()
+	if err != nil {
+		p.active.Add(-1)
+		return nil, err
+	}
+
+	resp.Body = &trackedBody{ReadCloser: resp.Body, done: func() { p.active.Add(-1) }}
+	return resp, nil
+}
+
+// Stats returns the current pool usage
+func (p *poolTracker) Stats() PoolStats {
+	return PoolStats{
+		MaxConns:    int(p.maxConns.Load()),
+		Active:      p.active.Load(),
+		Waiting:     p.waiting.Load(),
+		Saturated:   p.saturated.Load(),
+		WaitSeconds: time.Duration(p.waitNanos.Load()).Seconds(),
+	}
+}
+
+// trackedBody runs done once when the response body is closed
+type trackedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
@@ -0,0 +1,86 @@
+package sidecar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gokiq/internal/config"
+	"gokiq/internal/job"
+)
+
+func TestHTTPClient_PoolStats(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		json.NewEncoder(w).Encode(job.JobResult{Status: "success"})
+	}))
+	defer server.Close()
+
+	cfg := config.Default().Sidecar
+	cfg.URL = server.URL
+	cfg.Pool.MaxConns = 1
+	cfg.Pool.MaxIdleConns = 1
+	client := NewHTTPClientFromConfig(cfg)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(jid string) {
+			defer wg.Done()
+			if _, err := client.ExecuteJob(&job.SidekiqJob{Class: "SlowJob", JID: jid}); err != nil {
+				t.Errorf("ExecuteJob failed: %v", err)
+			}
+		}(string(rune('a' + i)))
+	}
+
+	// One request holds the only connection while the other waits for it
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stats := client.PoolStats()
+		if stats.Active == 2 && stats.Waiting == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected one active and one waiting request, got %+v", stats)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	stats := client.PoolStats()
+	if stats.MaxConns != 1 || stats.Active != 0 || stats.Waiting != 0 {
+		t.Errorf("Expected an idle pool after the requests, got %+v", stats)
+	}
+	if stats.Saturated != 1 || stats.WaitSeconds <= 0 {
+		t.Errorf("Expected one saturated request with wait time, got %+v", stats)
+	}
+}
+
+func TestHTTPClient_SetPool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(job.JobResult{Status: "success"})
+	}))
+	defer server.Close()
+
+	cfg := config.Default().Sidecar
+	cfg.URL = server.URL
+	cfg.Pool.MaxConns = 1
+	client := NewHTTPClientFromConfig(cfg)
+	if _, err := client.ExecuteJob(&job.SidekiqJob{Class: "TestJob", JID: "before"}); err != nil {
+		t.Fatalf("ExecuteJob failed: %v", err)
+	}
+
+	cfg.Pool.MaxConns = 8
+	client.SetPool(cfg.Pool)
+	if stats := client.PoolStats(); stats.MaxConns != 8 {
+		t.Errorf("Expected max conns 8 after resizing, got %+v", stats)
+	}
+	if _, err := client.ExecuteJob(&job.SidekiqJob{Class: "TestJob", JID: "after"}); err != nil {
+		t.Fatalf("ExecuteJob failed after resizing: %v", err)
+	}
+}
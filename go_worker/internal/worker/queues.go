@@ -0,0 +1,45 @@
+package worker
+
+import "math/rand"
+
+// queueOrder returns the order in which queues are polled. Without weights
+// queues are polled in strict priority order. With weights the order is
+// shuffled on every poll so each queue comes first in proportion to its
+// weight, like Sidekiq's weighted queues; unweighted queues count as 1.
+func queueOrder(queues []string, weights map[string]int) []string {
+	if len(weights) == 0 {
+		return queues
+	}
+
+	remaining := make([]string, len(queues))
+	copy(remaining, queues)
+
+	total := 0
+	for _, queue := range remaining {
+		total += queueWeight(queue, weights)
+	}
+
+	order := make([]string, 0, len(queues))
+	for len(remaining) > 0 {
+		pick := rand.Intn(total)
+		for i, queue := range remaining {
+			weight := queueWeight(queue, weights)
+			if pick < weight {
+				order = append(order, queue)
+				total -= weight
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+			pick -= weight
+		}
+	}
+
+	return order
+}
+
+func queueWeight(queue string, weights map[string]int) int {
+	if weight, ok := weights[queue]; ok && weight > 0 {
+		return weight
+	}
+	return 1
+}
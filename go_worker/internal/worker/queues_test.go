@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueueOrder_StrictWithoutWeights(t *testing.T) {
+	queues := []string{"critical", "default", "low"}
+
+	for i := 0; i < 10; i++ {
+		if order := queueOrder(queues, nil); !reflect.DeepEqual(order, queues) {
+			t.Fatalf("Expected strict order %v, got %v", queues, order)
+		}
+	}
+}
+
+func TestQueueOrder_Weighted(t *testing.T) {
+	queues := []string{"critical", "default", "low"}
+	weights := map[string]int{"critical": 6, "default": 3}
+
+	first := map[string]int{}
+	const rounds = 10000
+	for i := 0; i < rounds; i++ {
+		order := queueOrder(queues, weights)
+		if len(order) != len(queues) {
+			t.Fatalf("Expected every queue exactly once, got %v", order)
+		}
+		seen := map[string]bool{}
+		for _, q := range order {
+			if seen[q] {
+				t.Fatalf("Queue %s polled twice in %v", q, order)
+			}
+			seen[q] = true
+		}
+		first[order[0]]++
+	}
+
+	// Expected shares are 6/10, 3/10 and 1/10
+	expect := map[string]float64{"critical": 0.6, "default": 0.3, "low": 0.1}
+	for queue, share := range expect {
+		got := float64(first[queue]) / rounds
+		if got < share-0.05 || got > share+0.05 {
+			t.Errorf("Queue %s polled first %.2f of the time, expected about %.2f", queue, got, share)
+		}
+	}
+
+	if !reflect.DeepEqual(queues, []string{"critical", "default", "low"}) {
+		t.Errorf("queueOrder must not modify its input, got %v", queues)
+	}
+}
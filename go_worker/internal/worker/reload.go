@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"log"
+	"path"
+	"strings"
+
+	"gokiq/internal/concurrency"
+	"gokiq/internal/config"
+)
+
+// liveFields are the config paths (path prefixes ending in ".", or patterns
+// with "*" for a map key) that can be applied without restarting the process
+var liveFields = []string{
+	"worker.concurrency",
+	"worker.adaptive.min",
+	"worker.adaptive.max",
+	"worker.queues",
+	"worker.weights",
+	"worker.poll_interval",
+	"retry.",
+	"expiration.",
+	"sidecar.timeout",
+	"sidecar.pool.",
+	"executors.*.timeout",
+	"executors.*.pool.*",
+}
+
+// reload re-reads the configuration and applies every change that is safe to
+// make while jobs are running. Other changes are logged and ignored until restart.
+func (w *Worker) reload() {
+	current := w.config()
+
+	next, err := current.Reload()
+	if err != nil {
+		log.Printf("Configuration reload failed, keeping current configuration: %v", err)
+		return
+	}
+
+	applied, restart := partitionChanges(config.Diff(current, next))
+	var ignored []string
+	if w.adaptive != nil {
+		applied, ignored = withoutField(applied, "worker.concurrency")
+	}
+	if len(applied) == 0 && len(restart) == 0 && len(ignored) == 0 {
+		log.Printf("Configuration reloaded, no changes")
+		return
+	}
+
+	w.apply(current, next)
+
+	if len(applied) > 0 {
+		log.Printf("Configuration reloaded, applied: %s", strings.Join(applied, ", "))
+	}
+	if len(ignored) > 0 {
+		log.Printf("Configuration changes ignored while adaptive concurrency is enabled: %s", strings.Join(ignored, ", "))
+	}
+	if len(restart) > 0 {
+		log.Printf("Configuration changes require a restart and were not applied: %s", strings.Join(restart, ", "))
+	}
+}
+
+// apply installs the live-reloadable parts of next on top of current
+func (w *Worker) apply(current, next *config.Config) {
+	effective := *current
+	if w.adaptive == nil {
+		effective.Worker.Concurrency = next.Worker.Concurrency
+	}
+	effective.Worker.Queues = next.Worker.Queues
+	effective.Worker.Weights = next.Worker.Weights
+	effective.Worker.PollInterval = next.Worker.PollInterval
+	if next.Worker.Adaptive.Enabled {
+		effective.Worker.Adaptive.Min = next.Worker.Adaptive.Min
+		effective.Worker.Adaptive.Max = next.Worker.Adaptive.Max
+	}
+	effective.Retry = next.Retry
+	effective.Expiration = next.Expiration
+	effective.Sidecar.Timeout = next.Sidecar.Timeout
+	effective.Sidecar.Pool = next.Sidecar.Pool
+	effective.Executors = make(map[string]config.SidecarConfig, len(current.Executors))
+	for name, executor := range current.Executors {
+		if reloaded, ok := next.Executors[name]; ok {
+			executor.Timeout = reloaded.Timeout
+			executor.Pool = reloaded.Pool
+		}
+		effective.Executors[name] = executor
+	}
+
+	// The adaptive controller owns the limit, so only its bounds change
+	if w.adaptive != nil {
+		w.adaptive.SetBounds(effective.Worker.Adaptive.Min, effective.Worker.Adaptive.Max)
+	} else {
+		w.processor.SetConcurrency(effective.Worker.Concurrency)
+	}
+	w.processor.SetRetryPolicy(concurrency.NewRetryPolicy(effective.Retry))
+	w.processor.SetExpirationPolicy(concurrency.NewExpirationPolicy(effective.Expiration))
+	w.sidecarClient.SetTimeout(effective.Sidecar.Timeout)
+	// Pools derived from concurrency grow and shrink with it
+	if effective.Sidecar.Pool != current.Sidecar.Pool {
+		w.sidecarClient.SetPool(effective.Sidecar.Pool)
+	}
+	for name, executor := range effective.Executors {
+		if client, ok := w.dispatcher.Client(name); ok {
+			client.SetTimeout(executor.Timeout)
+			if executor.Pool != current.Executors[name].Pool {
+				client.SetPool(executor.Pool)
+			}
+		}
+	}
+
+	w.mu.Lock()
+	w.cfg = &effective
+	w.info.Queues = effective.Worker.Queues
+	w.info.Concurrency = w.processor.Capacity()
+	w.mu.Unlock()
+}
+
+// partitionChanges splits changed config paths into those applied live and
+// those that need a restart
+func partitionChanges(changed []string) (applied, restart []string) {
+	for _, path := range changed {
+		if isLiveField(path) {
+			applied = append(applied, path)
+		} else {
+			restart = append(restart, path)
+		}
+	}
+	return applied, restart
+}
+
+// withoutField splits field out of changed
+func withoutField(changed []string, field string) (rest, removed []string) {
+	for _, path := range changed {
+		if path == field {
+			removed = append(removed, path)
+		} else {
+			rest = append(rest, path)
+		}
+	}
+	return rest, removed
+}
+
+func isLiveField(changed string) bool {
+	for _, field := range liveFields {
+		if changed == field || (strings.HasSuffix(field, ".") && strings.HasPrefix(changed, field)) {
+			return true
+		}
+		if matched, _ := path.Match(field, changed); strings.Contains(field, "*") && matched {
+			return true
+		}
+	}
+	return false
+}
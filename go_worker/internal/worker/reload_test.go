@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gokiq/internal/concurrency"
+	"gokiq/internal/config"
+	"gokiq/internal/job"
+	"gokiq/internal/sidecar"
+)
+
+type noopExecutor struct{}
+
+func (noopExecutor) ExecuteJob(*job.SidekiqJob) (*job.JobResult, error) {
+	return &job.JobResult{Status: "success"}, nil
+}
+
+func TestPartitionChanges(t *testing.T) {
+	applied, restart := partitionChanges([]string{
+		"redis.url", "worker.queues", "worker.concurrency", "retry.max_attempts", "sidecar.timeout", "sidecar.url",
+		"executors.billing.timeout", "executors.billing.url", "executors.search",
+	})
+
+	if want := []string{"worker.queues", "worker.concurrency", "retry.max_attempts", "sidecar.timeout", "executors.billing.timeout"}; !reflect.DeepEqual(applied, want) {
+		t.Errorf("applied = %v, want %v", applied, want)
+	}
+	if want := []string{"redis.url", "sidecar.url", "executors.billing.url", "executors.search"}; !reflect.DeepEqual(restart, want) {
+		t.Errorf("restart = %v, want %v", restart, want)
+	}
+}
+
+func TestWorker_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+
+	write(`
+redis:
+  url: "redis-a:6379"
+sidecar:
+  timeout: 10s
+executors:
+  reports:
+    url: "http://reports:9292"
+worker:
+  concurrency: 5
+  queues: ["default"]
+retry:
+  max_attempts: 25
+`)
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	dispatcher := sidecar.NewDispatcherFromConfig(cfg)
+	sidecarClient, _ := dispatcher.Client(sidecar.DefaultExecutor)
+	w := &Worker{
+		cfg:           cfg,
+		sidecarClient: sidecarClient,
+		dispatcher:    dispatcher,
+		processor:     concurrency.NewConcurrentProcessor(cfg.Worker.Concurrency, noopExecutor{}),
+		info:          newProcessInfo(cfg),
+	}
+	defer w.processor.Shutdown(time.Second)
+
+	write(`
+redis:
+  url: "redis-b:6379"
+sidecar:
+  timeout: 2s
+executors:
+  reports:
+    url: "http://reports:9292"
+    timeout: 45s
+worker:
+  concurrency: 50
+  queues: ["critical", "default"]
+  weights:
+    critical: 3
+retry:
+  max_attempts: 3
+`)
+	w.reload()
+
+	got := w.config()
+	if !reflect.DeepEqual(got.Worker.Queues, []string{"critical", "default"}) {
+		t.Errorf("Expected queues to be reloaded, got %v", got.Worker.Queues)
+	}
+	if got.Worker.Weights["critical"] != 3 {
+		t.Errorf("Expected weights to be reloaded, got %v", got.Worker.Weights)
+	}
+	if w.processor.Capacity() != 50 || w.processInfo().Concurrency != 50 {
+		t.Errorf("Expected concurrency to be resized to 50, got %d", w.processor.Capacity())
+	}
+	if w.sidecarClient.PoolStats().MaxConns != 50 {
+		t.Errorf("Expected the derived sidecar pool to grow to 50, got %+v", w.sidecarClient.PoolStats())
+	}
+	if w.processor.RetryPolicy().MaxAttempts != 3 {
+		t.Errorf("Expected retry policy to be applied, got %+v", w.processor.RetryPolicy())
+	}
+	if w.sidecarClient.Timeout() != 2*time.Second {
+		t.Errorf("Expected sidecar timeout to be applied, got %v", w.sidecarClient.Timeout())
+	}
+	if reports, _ := w.dispatcher.Client("reports"); reports.Timeout() != 45*time.Second {
+		t.Errorf("Expected executor timeout to be applied, got %v", reports.Timeout())
+	}
+	if !reflect.DeepEqual(w.processInfo().Queues, []string{"critical", "default"}) {
+		t.Errorf("Expected heartbeat queues to be updated, got %v", w.processInfo().Queues)
+	}
+
+	// Restart-only changes are kept at their original values
+	if got.Redis.URL != "redis-a:6379" {
+		t.Errorf("Expected redis.url to require restart, got %s", got.Redis.URL)
+	}
+
+	// An invalid file leaves the running configuration untouched
+	write("worker:\n  poll_interval: 0s\n")
+	w.reload()
+	if w.config() != got {
+		t.Error("Expected invalid configuration to be rejected")
+	}
+}
+
+func TestWorker_ReloadAdaptiveBounds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+
+	write("worker:\n  concurrency: 8\n  adaptive:\n    enabled: true\n    min: 2\n    max: 20\n")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	w := &Worker{
+		cfg:           cfg,
+		sidecarClient: sidecar.NewHTTPClient(cfg.Sidecar.URL, cfg.Sidecar.Timeout),
+		processor:     concurrency.NewConcurrentProcessor(cfg.Worker.Concurrency, noopExecutor{}),
+		info:          newProcessInfo(cfg),
+	}
+	defer w.processor.Shutdown(time.Second)
+	w.adaptive = concurrency.NewAdaptiveController(w.processor, cfg.Worker.Adaptive)
+
+	// The controller keeps its limit when only the starting concurrency changes
+	write("worker:\n  concurrency: 16\n  adaptive:\n    enabled: true\n    min: 2\n    max: 20\n")
+	w.reload()
+	if w.processor.Capacity() != 8 {
+		t.Errorf("Expected the adaptive limit to be kept, got %d", w.processor.Capacity())
+	}
+	if w.config().Worker.Concurrency != 8 || w.processInfo().Concurrency != 8 {
+		t.Errorf("Expected concurrency to stay at the running limit, got config=%d info=%d",
+			w.config().Worker.Concurrency, w.processInfo().Concurrency)
+	}
+
+	write("worker:\n  concurrency: 16\n  adaptive:\n    enabled: true\n    min: 2\n    max: 4\n")
+	w.reload()
+	if status := w.adaptive.Status(); status.Max != 4 || status.Limit != 4 {
+		t.Errorf("Expected the limit clamped to the new bounds, got %+v", status)
+	}
+}
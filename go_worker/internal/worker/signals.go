@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime/pprof"
+	"syscall"
+	"time"
+)
+
+// remoteSignals maps the names pushed onto <identity>-signals by the Sidekiq
+// Web UI (or gokiq signal) to the local signal they stand for
+var remoteSignals = map[string]os.Signal{
+	"TSTP": quietSignal,
+	"TTIN": dumpSignal,
+	"TERM": syscall.SIGTERM,
+}
+
+// handleSignal acts on a local or remote signal and reports whether the
+// worker should shut down
+func (w *Worker) handleSignal(sig os.Signal) bool {
+	switch sig {
+	case syscall.SIGHUP:
+		w.reload()
+	case quietSignal:
+		w.quiet()
+	case dumpSignal:
+		w.dump(log.Writer())
+	default:
+		log.Printf("Received signal %v, initiating shutdown...", sig)
+		return true
+	}
+	return false
+}
+
+// quiet stops fetching new jobs while letting in-flight jobs finish
+func (w *Worker) quiet() {
+	if w.quieted.Swap(true) {
+		return
+	}
+	log.Printf("Quieting: no longer fetching new jobs, %d in progress", w.processor.ActiveJobs())
+}
+
+// dump writes the running jobs and every goroutine's stack to out
+func (w *Worker) dump(out io.Writer) {
+	running := w.processor.RunningJobs()
+	fmt.Fprintf(out, "Thread dump: %d active jobs\n", len(running))
+	for _, r := range running {
+		fmt.Fprintf(out, "  JID=%s, Class=%s, Queue=%s, Running=%v\n",
+			r.Job.JID, r.Job.Class, r.Job.Queue, time.Since(r.StartedAt).Round(time.Millisecond))
+	}
+
+	if err := pprof.Lookup("goroutine").WriteTo(out, 2); err != nil {
+		log.Printf("Failed to dump goroutines: %v", err)
+	}
+}
+
+// checkSignals delivers signals sent to this process through Redis
+func (w *Worker) checkSignals(ctx context.Context) {
+	identity := w.processInfo().Identity
+	for {
+		name, err := w.redisClient.PopSignal(identity)
+		if err != nil {
+			log.Printf("Failed to check remote signals: %v", err)
+			return
+		}
+		if name == "" {
+			return
+		}
+
+		sig, ok := remoteSignals[name]
+		if !ok {
+			log.Printf("Ignoring unknown remote signal %q", name)
+			continue
+		}
+
+		log.Printf("Received remote signal %s", name)
+		select {
+		case w.signals <- sig:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"bytes"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"gokiq/internal/concurrency"
+	"gokiq/internal/job"
+)
+
+type blockingExecutor struct {
+	release chan struct{}
+}
+
+func (e blockingExecutor) ExecuteJob(*job.SidekiqJob) (*job.JobResult, error) {
+	<-e.release
+	return &job.JobResult{Status: "success"}, nil
+}
+
+func TestWorker_HandleSignal(t *testing.T) {
+	executor := blockingExecutor{release: make(chan struct{})}
+	w := &Worker{processor: concurrency.NewConcurrentProcessor(2, executor)}
+	defer w.processor.Shutdown(time.Second)
+	defer close(executor.release)
+
+	if w.handleSignal(quietSignal) {
+		t.Error("TSTP must not stop the worker")
+	}
+	if !w.quieted.Load() {
+		t.Error("TSTP should quiet the worker")
+	}
+
+	// Quieting twice is harmless
+	w.handleSignal(remoteSignals["TSTP"])
+	if !w.quieted.Load() {
+		t.Error("Worker should remain quiet")
+	}
+
+	if !w.handleSignal(syscall.SIGTERM) || !w.handleSignal(remoteSignals["TERM"]) {
+		t.Error("TERM should stop the worker")
+	}
+}
+
+func TestWorker_Dump(t *testing.T) {
+	executor := blockingExecutor{release: make(chan struct{})}
+	w := &Worker{processor: concurrency.NewConcurrentProcessor(2, executor)}
+	defer w.processor.Shutdown(time.Second)
+	defer close(executor.release)
+
+	if err := w.processor.ProcessJob(&job.SidekiqJob{JID: "jid-running", Class: "SlowJob", Queue: "default"}); err != nil {
+		t.Fatalf("ProcessJob failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	w.dump(&out)
+
+	dump := out.String()
+	if !strings.Contains(dump, "1 active jobs") || !strings.Contains(dump, "JID=jid-running, Class=SlowJob") {
+		t.Errorf("Expected running job in dump, got:\n%s", dump)
+	}
+	if !strings.Contains(dump, "goroutine ") {
+		t.Error("Expected goroutine stacks in dump")
+	}
+}
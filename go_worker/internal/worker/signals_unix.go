@@ -0,0 +1,11 @@
+//go:build !windows
+
+package worker
+
+import "syscall"
+
+// quietSignal and dumpSignal follow Sidekiq: TSTP quiets, TTIN dumps state
+var (
+	quietSignal = syscall.SIGTSTP
+	dumpSignal  = syscall.SIGTTIN
+)
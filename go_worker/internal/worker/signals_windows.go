@@ -0,0 +1,15 @@
+//go:build windows
+
+package worker
+
+// remoteOnlySignal is a signal that can only be delivered through Redis,
+// since Windows has no TSTP or TTIN
+type remoteOnlySignal string
+
+func (s remoteOnlySignal) String() string { return string(s) }
+func (s remoteOnlySignal) Signal()        {}
+
+var (
+	quietSignal = remoteOnlySignal("TSTP")
+	dumpSignal  = remoteOnlySignal("TTIN")
+)
@@ -0,0 +1,471 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"gokiq/internal/admin"
+	"gokiq/internal/batch"
+	"gokiq/internal/blob"
+	"gokiq/internal/concurrency"
+	"gokiq/internal/config"
+	"gokiq/internal/cron"
+	"gokiq/internal/encryption"
+	"gokiq/internal/handler"
+	"gokiq/internal/job"
+	"gokiq/internal/redis"
+	"gokiq/internal/sidecar"
+	"gokiq/internal/workflow"
+)
+
+const (
+	heartbeatInterval = 10 * time.Second
+	scheduleInterval  = 5 * time.Second
+	schedulePageSize  = 100
+	shutdownTimeout   = 30 * time.Second
+)
+
+// Worker wires polling, processing, heartbeats and the admin API into a
+// single Sidekiq-compatible process
+type Worker struct {
+	cfg           *config.Config
+	redisClient   *redis.Client
+	sidecarClient *sidecar.HTTPClient
+	dispatcher    *sidecar.Dispatcher
+	handlers      *handler.Registry
+	processor     *concurrency.ConcurrentProcessor
+	adminServer   *admin.Server
+	adaptive      *concurrency.AdaptiveController
+	cron          *cron.Scheduler
+	info          redis.ProcessInfo
+	mu            sync.RWMutex
+
+	// signals receives both OS signals and remote signals read from Redis
+	signals chan os.Signal
+	quieted atomic.Bool
+}
+
+// New creates a worker and connects to Redis
+func New(cfg *config.Config) (*Worker, error) {
+	// Initialize Redis client
+	redisClient, err := redis.NewClient(cfg.Redis)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Redis client: %w", err)
+	}
+
+	// Initialize a Sidecar client per executor
+	dispatcher := sidecar.NewDispatcherFromConfig(cfg)
+	for _, name := range dispatcher.Names() {
+		name := name
+		client, _ := dispatcher.Client(name)
+		client.SetOutcomeStore(redisClient)
+		if cfg.Encryption.Enabled {
+			client.RedactErrorBodies()
+		}
+		client.OnBreakerStateChange(func(from, to sidecar.CircuitState) {
+			log.Printf("Sidecar circuit breaker (%s) %s -> %s", name, from, to)
+		})
+	}
+	sidecarClient, _ := dispatcher.Client(sidecar.DefaultExecutor)
+
+	// Classes with a registered Go handler run in-process, the rest in Rails
+	handlers := handler.NewRegistry()
+	router := handler.NewRouter(handlers, dispatcher)
+
+	w := &Worker{
+		cfg:           cfg,
+		redisClient:   redisClient,
+		sidecarClient: sidecarClient,
+		dispatcher:    dispatcher,
+		handlers:      handlers,
+		processor:     concurrency.NewConcurrentProcessor(cfg.Worker.Concurrency, router),
+		info:          newProcessInfo(cfg),
+		signals:       make(chan os.Signal, 4),
+	}
+	w.processor.EnableRetries(redisClient, concurrency.NewRetryPolicy(cfg.Retry))
+	w.processor.SetExpirationPolicy(concurrency.NewExpirationPolicy(cfg.Expiration))
+	w.processor.Middleware().Add("recover", concurrency.Recover)
+	w.processor.Middleware().Prepend("batch", batch.Middleware(redisClient))
+	w.processor.Middleware().Prepend("workflow", workflow.Middleware(redisClient))
+	w.processor.OnDeath(batch.DeathHandler(redisClient))
+	w.processor.OnDeath(workflow.DeathHandler(redisClient))
+
+	if cfg.Encryption.Enabled {
+		keyring, err := encryption.NewKeyring(cfg.Encryption)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize encryption: %w", err)
+		}
+		redisClient.SetEncrypter(keyring)
+		if err := w.processor.Middleware().InsertAfter("batch", "encryption", encryption.Middleware(keyring)); err != nil {
+			return nil, err
+		}
+	}
+
+	store, err := blob.NewStore(cfg.Blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize blob store: %w", err)
+	}
+	if store != nil {
+		if cfg.Blob.Threshold > 0 {
+			redisClient.SetOffloader(blob.NewOffloader(store, cfg.Blob.Threshold))
+		}
+		// After batch and workflow, so they see jobs whose blob is missing
+		// fail, and before encryption, as blobs hold encrypted args
+		if err := w.processor.Middleware().InsertAfter("batch", "blob", blob.Middleware(store)); err != nil {
+			return nil, err
+		}
+		w.processor.OnDrop(blob.DropHandler(store))
+	}
+
+	if cfg.Worker.Adaptive.Enabled {
+		w.adaptive = concurrency.NewAdaptiveController(w.processor, cfg.Worker.Adaptive)
+	}
+
+	if len(cfg.Cron.Jobs) > 0 {
+		w.cron, err = newCronScheduler(cfg.Cron, redisClient, w.info.Identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Admin.Enabled {
+		w.adminServer = admin.NewServer(cfg.Admin, redisClient)
+		if w.adaptive != nil {
+			w.adminServer.SetConcurrencyReporter(w.adaptive)
+		}
+		w.adminServer.SetPoolReporter(w)
+	}
+
+	return w, nil
+}
+
+// Use registers server middleware under name, run after any already
+// registered. worker.middleware in the configuration can select and reorder it.
+func (w *Worker) Use(name string, middleware concurrency.Middleware) {
+	w.processor.Middleware().Add(name, middleware)
+}
+
+// Handle executes jobs of the Sidekiq class in-process with fn instead of
+// sending them to the sidecar
+func (w *Worker) Handle(class string, fn handler.Func) error {
+	return w.handlers.Register(class, fn)
+}
+
+// RunBatch records b and pushes its jobs
+func (w *Worker) RunBatch(b *batch.Batch) error {
+	return b.Run(w.redisClient)
+}
+
+// RunWorkflow records wf and pushes the steps without parents
+func (w *Worker) RunWorkflow(wf *workflow.Workflow) error {
+	return wf.Run(w.redisClient)
+}
+
+// Run processes jobs until SIGINT or SIGTERM is received, then shuts down
+// gracefully. SIGHUP reloads the configuration, TSTP stops fetching new jobs
+// and TTIN logs the running jobs and goroutine stacks.
+func (w *Worker) Run() error {
+	defer w.redisClient.Close()
+
+	if order := w.cfg.Worker.Middleware; len(order) > 0 {
+		if err := w.processor.Middleware().Reorder(order); err != nil {
+			return fmt.Errorf("invalid worker.middleware: %w", err)
+		}
+	}
+
+	// Context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle OS signals
+	signal.Notify(w.signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, quietSignal, dumpSignal)
+	defer signal.Stop(w.signals)
+
+	if w.adminServer != nil {
+		go func() {
+			if err := w.adminServer.ListenAndServe(); err != nil {
+				log.Printf("Admin API error: %v", err)
+			}
+		}()
+	}
+
+	log.Printf("Go Sidekiq Worker started (identity: %s, concurrency: %d, queues: %v, middleware: %v, go handlers: %v)",
+		w.info.Identity, w.cfg.Worker.Concurrency, w.cfg.Worker.Queues, w.processor.Middleware().Names(), w.handlers.Classes())
+
+	processorStopped := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		w.heartbeat(ctx, processorStopped)
+	}()
+	go w.schedule(ctx)
+	fetchDone := make(chan struct{})
+	go func() {
+		defer close(fetchDone)
+		w.fetch(ctx)
+	}()
+	if w.adaptive != nil {
+		go w.adaptive.Run(ctx)
+	}
+	cronDone := make(chan struct{})
+	if w.cron != nil {
+		go func() {
+			defer close(cronDone)
+			w.cron.Run(ctx)
+		}()
+	} else {
+		close(cronDone)
+	}
+
+	// Wait for termination signal
+	for sig := range w.signals {
+		if w.handleSignal(sig) {
+			break
+		}
+	}
+
+	// Cancel context and shutdown processor
+	cancel()
+	if err := w.processor.Shutdown(shutdownTimeout); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
+	// A job the fetcher popped during shutdown is pushed back before Redis closes
+	<-fetchDone
+	close(processorStopped)
+	<-heartbeatDone
+	<-cronDone
+
+	if w.adminServer != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := w.adminServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Admin API shutdown error: %v", err)
+		}
+		shutdownCancel()
+	}
+
+	if err := w.redisClient.RemoveProcess(w.info.Identity); err != nil {
+		log.Printf("Failed to unregister process: %v", err)
+	}
+
+	log.Println("Worker stopped")
+	return nil
+}
+
+// newCronScheduler builds the scheduler for the periodic jobs in cfg
+func newCronScheduler(cfg config.CronConfig, store cron.Store, identity string) (*cron.Scheduler, error) {
+	entries := make([]cron.Entry, 0, len(cfg.Jobs))
+	for _, jobCfg := range cfg.Jobs {
+		catchUp := jobCfg.CatchUp
+		if catchUp == "" {
+			catchUp = cfg.CatchUp
+		}
+		entry, err := cron.NewEntry(jobCfg.Name, jobCfg.Class, jobCfg.Queue, jobCfg.Args, jobCfg.Schedule, jobCfg.Timezone, catchUp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron job: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return cron.NewScheduler(store, identity, entries, cfg.LeaderTTL, cfg.Grace, cfg.MaxCatchUp), nil
+}
+
+// fetch is the main worker loop, polling Redis and handing jobs to the processor
+func (w *Worker) fetch(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			cfg := w.config()
+
+			// Leave jobs in Redis while quiet, and skip queues whose jobs only
+			// route to executors with an open breaker
+			queues := w.dispatcher.ReadyQueues(queueOrder(cfg.Worker.Queues, cfg.Worker.Weights))
+			if w.quieted.Load() || len(queues) == 0 {
+				time.Sleep(cfg.Worker.PollInterval)
+				continue
+			}
+
+			// Poll for jobs
+			polled, err := w.redisClient.PollJobs(queues)
+			if err != nil {
+				log.Printf("Error polling jobs: %v", err)
+				time.Sleep(1 * time.Second) // Backoff on error
+				continue
+			}
+
+			if polled == nil {
+				// No jobs available, sleep briefly
+				time.Sleep(cfg.Worker.PollInterval)
+				continue
+			}
+
+			// A queue may mix jobs for ready and open executors; give back
+			// the latter rather than burn one of their retries
+			if _, inProcess := w.handlers.Lookup(polled.Class); !inProcess && !w.dispatcher.ReadyFor(polled) {
+				if err := w.redisClient.PushBack(polled); err != nil {
+					log.Printf("Failed to push back job %s: %v", polled.JID, err)
+				}
+				time.Sleep(cfg.Worker.PollInterval)
+				continue
+			}
+
+			// Process the job, or give it back when the processor is shutting down
+			if err := w.processor.ProcessJob(polled); err != nil {
+				log.Printf("Error submitting job for processing, requeuing: JID=%s, Error=%v", polled.JID, err)
+				if err := w.redisClient.RequeueInterrupted([]*job.SidekiqJob{polled}); err != nil {
+					log.Printf("Failed to requeue job %s: %v", polled.JID, err)
+				}
+			}
+		}
+	}
+}
+
+// heartbeat periodically registers this process, flushes job counters and
+// picks up remote signals. Once ctx is done it waits for processorStopped and
+// flushes the counts of the jobs that finished during shutdown.
+func (w *Worker) heartbeat(ctx context.Context, processorStopped <-chan struct{}) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	var flushedProcessed, flushedFailed, flushedExpired int64
+	flush := func() {
+		processed := w.processor.ProcessedCount()
+		failed := w.processor.FailedCount()
+		expired := w.processor.ExpiredCount()
+		err := w.redisClient.Heartbeat(w.processInfo(), w.processor.ActiveJobs(), w.quieted.Load(),
+			processed-flushedProcessed, failed-flushedFailed, expired-flushedExpired)
+		if err != nil {
+			log.Printf("Heartbeat failed: %v", err)
+		} else {
+			flushedProcessed, flushedFailed, flushedExpired = processed, failed, expired
+		}
+	}
+
+	pools := w.PoolStats()
+	for {
+		flush()
+		w.checkSignals(ctx)
+		pools = w.logPoolSaturation(pools)
+
+		select {
+		case <-ctx.Done():
+			<-processorStopped
+			flush()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PoolStats reports the usage of the Redis and sidecar connection pools
+func (w *Worker) PoolStats() admin.PoolStats {
+	return admin.PoolStats{
+		Redis:    w.redisClient.PoolStats(),
+		Sidecars: w.dispatcher.PoolStats(),
+	}
+}
+
+// logPoolSaturation logs every pool that made callers wait since previous,
+// returning the current stats for the next comparison
+func (w *Worker) logPoolSaturation(previous admin.PoolStats) admin.PoolStats {
+	current := w.PoolStats()
+
+	if timeouts := current.Redis.Timeouts - previous.Redis.Timeouts; timeouts > 0 {
+		log.Printf("Connection pool saturated: pool=redis, size=%d, in_use=%d, timeouts=%d",
+			current.Redis.Size, current.Redis.InUse, timeouts)
+	}
+	for name, stats := range current.Sidecars {
+		if saturated := stats.Saturated - previous.Sidecars[name].Saturated; saturated > 0 {
+			log.Printf("Connection pool saturated: pool=%s, max_conns=%d, waiting=%d, waited=%d, wait_time=%.3fs",
+				name, stats.MaxConns, stats.Waiting, saturated, stats.WaitSeconds-previous.Sidecars[name].WaitSeconds)
+		}
+	}
+	return current
+}
+
+// schedule moves due jobs from the schedule and retry sets back onto their queues
+func (w *Worker) schedule(ctx context.Context) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, set := range []string{redis.ScheduleSet, redis.RetrySet} {
+			w.enqueueDue(ctx, set)
+		}
+	}
+}
+
+// enqueueDue moves every due job in set onto its queue, a page at a time,
+// until nothing is due or a Redis call fails
+func (w *Worker) enqueueDue(ctx context.Context, set string) {
+	for ctx.Err() == nil {
+		now := strconv.FormatInt(time.Now().Unix(), 10)
+		due, err := w.redisClient.ScanSet(set, "-inf", now, 0, schedulePageSize)
+		if err != nil {
+			log.Printf("Error polling %s set: %v", set, err)
+			return
+		}
+
+		for i := range due {
+			due[i].Job.EnqueuedAt = float64(time.Now().UnixNano()) / 1e9
+
+			// Another process may have moved the job already, which is fine
+			if _, err := w.redisClient.RequeueFromSet(set, &due[i]); err != nil {
+				log.Printf("Error enqueuing job %s from %s set: %v", due[i].Job.JID, set, err)
+				return
+			}
+		}
+
+		if len(due) < schedulePageSize {
+			return
+		}
+	}
+}
+
+// config returns the currently active configuration
+func (w *Worker) config() *config.Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cfg
+}
+
+// processInfo returns the process description reported in heartbeats
+func (w *Worker) processInfo() redis.ProcessInfo {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.info
+}
+
+// newProcessInfo builds the Sidekiq process description for this worker
+func newProcessInfo(cfg *config.Config) redis.ProcessInfo {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	pid := os.Getpid()
+
+	return redis.ProcessInfo{
+		Identity:    fmt.Sprintf("%s:%d:%s", hostname, pid, job.NewJID()[:12]),
+		Hostname:    hostname,
+		PID:         pid,
+		Tag:         "gokiq",
+		StartedAt:   float64(time.Now().UnixNano()) / 1e9,
+		Concurrency: cfg.Worker.Concurrency,
+		Queues:      cfg.Worker.Queues,
+		Labels:      []string{},
+	}
+}
@@ -0,0 +1,20 @@
+package workflow
+
+import (
+	"gokiq/internal/redis"
+)
+
+// Store persists workflows and the state of their steps
+type Store interface {
+	// CreateWorkflow records a workflow and pushes its root steps atomically
+	CreateWorkflow(id, description, policy string, steps []redis.WorkflowStep) error
+
+	// RecordStepOutcome records a finished step and pushes the steps it unblocks
+	RecordStepOutcome(id, step string, success bool) error
+
+	// Workflow returns the state of a workflow, or nil if it does not exist
+	Workflow(id string) (*redis.WorkflowStatus, error)
+
+	// Workflows returns the IDs of the most recent workflows, newest first
+	Workflows(offset, count int64) ([]string, error)
+}
@@ -0,0 +1,112 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"gokiq/internal/concurrency"
+	"gokiq/internal/job"
+	"gokiq/internal/redis"
+)
+
+// Policy decides what happens to the rest of a workflow when a step fails
+// with no retries left
+type Policy string
+
+const (
+	// Halt stops the workflow: steps that have not been pushed are cancelled
+	Halt Policy = "halt"
+
+	// SkipBranch skips every step that depends on the failed one, while
+	// independent branches carry on
+	SkipBranch Policy = "skip"
+
+	// Continue treats the failed step as finished, so its dependents still run
+	Continue Policy = "continue"
+)
+
+// Workflow is a DAG of Sidekiq jobs. A step is pushed once all of the steps
+// it runs after have succeeded (or, with Continue, finished).
+type Workflow struct {
+	ID          string
+	Description string
+	Policy      Policy
+
+	steps []redis.WorkflowStep
+	names map[string]bool
+}
+
+// New creates an empty workflow with a fresh ID
+func New(description string, policy Policy) *Workflow {
+	return &Workflow{
+		ID:          job.NewJID(),
+		Description: description,
+		Policy:      policy,
+		names:       make(map[string]bool),
+	}
+}
+
+// Add adds a step that runs jobData after the named steps. Parents must have
+// been added already, which keeps the graph acyclic.
+func (w *Workflow) Add(name string, jobData *job.SidekiqJob, after ...string) error {
+	if name == "" || strings.Contains(name, ",") {
+		return fmt.Errorf("invalid step name %q", name)
+	}
+	if w.names[name] {
+		return fmt.Errorf("step %s is already defined", name)
+	}
+	for _, parent := range after {
+		if !w.names[parent] {
+			return fmt.Errorf("step %s runs after unknown step %s", name, parent)
+		}
+	}
+
+	w.names[name] = true
+	w.steps = append(w.steps, redis.WorkflowStep{Name: name, Job: jobData, Parents: after})
+	return nil
+}
+
+// Run records the workflow and pushes the steps without parents
+func (w *Workflow) Run(store Store) error {
+	switch w.Policy {
+	case Halt, SkipBranch, Continue:
+	default:
+		return fmt.Errorf("unknown failure policy %q", w.Policy)
+	}
+
+	if err := store.CreateWorkflow(w.ID, w.Description, string(w.Policy), w.steps); err != nil {
+		return fmt.Errorf("failed to run workflow: %w", err)
+	}
+	return nil
+}
+
+// Middleware is server middleware that records every successful workflow
+// step, pushing the steps it unblocks
+func Middleware(store Store) concurrency.Middleware {
+	return func(ctx context.Context, jobData *job.SidekiqJob, next concurrency.Next) (*job.JobResult, error) {
+		result, err := next(ctx, jobData)
+		if jobData.WorkflowID != "" && err == nil && result != nil && result.Status == "success" {
+			recordOutcome(store, jobData, true)
+		}
+		return result, err
+	}
+}
+
+// DeathHandler records a workflow step that will not be retried again as
+// failed, applying the workflow's failure policy
+func DeathHandler(store Store) concurrency.DeathHandler {
+	return func(jobData *job.SidekiqJob, result *job.JobResult) {
+		if jobData.WorkflowID != "" {
+			recordOutcome(store, jobData, false)
+		}
+	}
+}
+
+func recordOutcome(store Store, jobData *job.SidekiqJob, success bool) {
+	if err := store.RecordStepOutcome(jobData.WorkflowID, jobData.WorkflowStep, success); err != nil {
+		log.Printf("Failed to update workflow: WID=%s, Step=%s, JID=%s, Error=%v",
+			jobData.WorkflowID, jobData.WorkflowStep, jobData.JID, err)
+	}
+}
@@ -0,0 +1,109 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"gokiq/internal/job"
+	"gokiq/internal/redis"
+)
+
+// outcome is a recorded call to RecordStepOutcome
+type outcome struct {
+	id, step string
+	success  bool
+}
+
+// fakeStore records workflows and step outcomes in memory
+type fakeStore struct {
+	policy   string
+	steps    []redis.WorkflowStep
+	outcomes []outcome
+}
+
+func (s *fakeStore) CreateWorkflow(id, description, policy string, steps []redis.WorkflowStep) error {
+	s.policy, s.steps = policy, steps
+	return nil
+}
+
+func (s *fakeStore) RecordStepOutcome(id, step string, success bool) error {
+	s.outcomes = append(s.outcomes, outcome{id, step, success})
+	return nil
+}
+
+func (s *fakeStore) Workflow(id string) (*redis.WorkflowStatus, error) { return nil, nil }
+
+func (s *fakeStore) Workflows(offset, count int64) ([]string, error) { return nil, nil }
+
+func TestWorkflow_Add(t *testing.T) {
+	wf := New("etl", SkipBranch)
+	steps := []struct {
+		name  string
+		after []string
+	}{
+		{"a", nil},
+		{"b", []string{"a"}},
+		{"c", []string{"a"}},
+		{"d", []string{"b", "c"}},
+	}
+	for _, step := range steps {
+		if err := wf.Add(step.name, &job.SidekiqJob{Class: "EtlJob"}, step.after...); err != nil {
+			t.Fatalf("Add(%s) failed: %v", step.name, err)
+		}
+	}
+
+	invalid := []struct {
+		name  string
+		after []string
+	}{
+		{"b", []string{"a"}},   // duplicate
+		{"e", []string{"f"}},   // unknown parent, also rules out cycles
+		{"", nil},              // empty name
+		{"x,y", []string{"a"}}, // comma
+	}
+	for _, step := range invalid {
+		if err := wf.Add(step.name, &job.SidekiqJob{Class: "EtlJob"}, step.after...); err == nil {
+			t.Errorf("Expected Add(%q, after %v) to fail", step.name, step.after)
+		}
+	}
+
+	store := &fakeStore{}
+	if err := wf.Run(store); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if store.policy != "skip" || len(store.steps) != 4 || len(store.steps[3].Parents) != 2 {
+		t.Errorf("Unexpected workflow stored: policy %s, steps %+v", store.policy, store.steps)
+	}
+
+	if err := New("bad", Policy("retry")).Run(store); err == nil {
+		t.Error("Expected error for an unknown policy")
+	}
+}
+
+func TestMiddlewareAndDeathHandler(t *testing.T) {
+	store := &fakeStore{}
+	middleware := Middleware(store)
+	succeed := func(ctx context.Context, jobData *job.SidekiqJob) (*job.JobResult, error) {
+		return &job.JobResult{Status: "success"}, nil
+	}
+	fail := func(ctx context.Context, jobData *job.SidekiqJob) (*job.JobResult, error) {
+		return &job.JobResult{Status: "failure"}, nil
+	}
+
+	middleware(context.Background(), &job.SidekiqJob{JID: "1", WorkflowID: "wf", WorkflowStep: "a"}, succeed)
+	// A failure may still be retried, so only the death handler records it
+	middleware(context.Background(), &job.SidekiqJob{JID: "2", WorkflowID: "wf", WorkflowStep: "b"}, fail)
+	middleware(context.Background(), &job.SidekiqJob{JID: "3"}, succeed)
+	DeathHandler(store)(&job.SidekiqJob{JID: "2", WorkflowID: "wf", WorkflowStep: "b"}, nil)
+	DeathHandler(store)(&job.SidekiqJob{JID: "4"}, nil)
+
+	want := []outcome{{"wf", "a", true}, {"wf", "b", false}}
+	if len(store.outcomes) != len(want) {
+		t.Fatalf("Expected %d outcomes, got %v", len(want), store.outcomes)
+	}
+	for i := range want {
+		if store.outcomes[i] != want[i] {
+			t.Errorf("Outcome %d = %+v, expected %+v", i, store.outcomes[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,84 @@
+require 'fileutils'
+require 'json'
+require 'securerandom'
+
+module Sidecar
+  module Blob
+    # Sidekiq client middleware that offloads large job args the way the Go
+    # worker does, so megabyte payloads enqueued from Rails stay out of Redis.
+    # Args whose JSON is larger than threshold bytes are stored as
+    # "<jid>.json" and replaced by:
+    #
+    #   "args": [], "args_ref": "<jid>.json"
+    #
+    # Use the same store and threshold as the worker's blob section. Add it
+    # after Sidecar::Encryption::ClientMiddleware, so blobs hold ciphertext:
+    #
+    #   Sidekiq.configure_client do |config|
+    #     config.client_middleware do |chain|
+    #       chain.add Sidecar::Encryption::ClientMiddleware, current: 'v1', keys: { ... }
+    #       chain.add Sidecar::Blob::ClientMiddleware,
+    #                 store: Sidecar::Blob::LocalStore.new('/mnt/gokiq-blobs')
+    #     end
+    #   end
+    class ClientMiddleware
+      DEFAULT_THRESHOLD = 100 * 1024
+
+      def initialize(store:, threshold: DEFAULT_THRESHOLD)
+        @store = store
+        @threshold = threshold
+      end
+
+      def call(_worker_class, job, _queue, _redis_pool)
+        offload(job) unless job['args_ref']
+        yield
+      end
+
+      private
+
+      def offload(job)
+        data = JSON.generate(job['args'])
+        return if data.bytesize <= @threshold
+
+        key = "#{job.fetch('jid')}.json"
+        @store.put(key, data)
+        job['args'] = []
+        job['args_ref'] = key
+      end
+    end
+
+    # LocalStore writes blobs as files under a directory shared with the
+    # workers (blob.backend "local")
+    class LocalStore
+      def initialize(dir)
+        @dir = dir
+        FileUtils.mkdir_p(dir)
+      end
+
+      # Writes to a temporary file and renames it into place, so workers never
+      # read a partial blob
+      def put(key, data)
+        path = File.join(@dir, key)
+        tmp = File.join(File.dirname(path), ".tmp-#{SecureRandom.hex(8)}")
+        File.binwrite(tmp, data)
+        File.rename(tmp, path)
+      ensure
+        FileUtils.rm_f(tmp) if tmp
+      end
+    end
+
+    # S3Store writes blobs with an Aws::S3::Client (blob.backend "s3") to
+    # <bucket>/<prefix><key>
+    class S3Store
+      def initialize(client:, bucket:, prefix: '')
+        @client = client
+        @bucket = bucket
+        @prefix = prefix
+      end
+
+      def put(key, data)
+        @client.put_object(bucket: @bucket, key: "#{@prefix}#{key}", body: data)
+      end
+    end
+  end
+end
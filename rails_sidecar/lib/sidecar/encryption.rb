@@ -0,0 +1,52 @@
+require 'base64'
+require 'json'
+require 'openssl'
+
+module Sidecar
+  module Encryption
+    # Sidekiq client middleware that encrypts job args the way the Go worker
+    # does, so jobs pushed from Rails are encrypted in Redis too:
+    #
+    #   "args": [base64(nonce + ciphertext + tag)], "encryption_key": "<key id>"
+    #
+    # The ciphertext is AES-GCM over the JSON args with a 12 byte nonce and the
+    # jid as additional authenticated data. Use the same key ring as the
+    # worker's encryption section:
+    #
+    #   Sidekiq.configure_client do |config|
+    #     config.client_middleware do |chain|
+    #       chain.add Sidecar::Encryption::ClientMiddleware,
+    #                 current: 'v1', keys: { 'v1' => ENV.fetch('GOKIQ_ENCRYPTION_KEY_V1') }
+    #     end
+    #   end
+    class ClientMiddleware
+      NONCE_SIZE = 12
+
+      def initialize(current:, keys:)
+        @current = current.to_s
+        @keys = keys.transform_keys(&:to_s).transform_values { |key| Base64.strict_decode64(key) }
+        raise ArgumentError, "current encryption key #{@current.inspect} is not in the key ring" unless @keys.key?(@current)
+      end
+
+      def call(_worker_class, job, _queue, _redis_pool)
+        encrypt(job) unless job['encryption_key']
+        yield
+      end
+
+      private
+
+      def encrypt(job)
+        key = @keys.fetch(@current)
+        cipher = OpenSSL::Cipher.new("aes-#{key.bytesize * 8}-gcm").encrypt
+        cipher.key = key
+        nonce = OpenSSL::Random.random_bytes(NONCE_SIZE)
+        cipher.iv = nonce
+        cipher.auth_data = job.fetch('jid')
+
+        ciphertext = cipher.update(JSON.generate(job['args'])) + cipher.final
+        job['args'] = [Base64.strict_encode64(nonce + ciphertext + cipher.auth_tag)]
+        job['encryption_key'] = @current
+      end
+    end
+  end
+end
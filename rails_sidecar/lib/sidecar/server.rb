@@ -1,7 +1,25 @@
 require 'json'
+require 'redis'
 
 module Sidecar
   class Server
+    # Idempotency markers live in Redis so every sidecar process sees them. A
+    # key is claimed while its attempt runs and then holds the response of a
+    # successful or discarded job; failures release it so retries run again.
+    IN_PROGRESS = 'in_progress'
+    IN_PROGRESS_TTL = 600
+    COMPLETED_TTL = 86_400
+
+    # How long a duplicate request waits for the running attempt to finish
+    IN_PROGRESS_WAIT = 10
+    IN_PROGRESS_POLL = 0.1
+
+    def initialize(redis: Redis.new(url: ENV.fetch('REDIS_URL', 'redis://localhost:6379/0')),
+                   namespace: ENV['GOKIQ_REDIS_NAMESPACE'])
+      @redis = redis
+      @prefix = namespace.to_s.empty? ? '' : "#{namespace}:"
+    end
+
     def call(env)
       request = Rack::Request.new(env)
 
@@ -27,34 +45,104 @@ module Sidecar
       args = payload['args']
       jid = payload['jid']
 
+      # A transport retry of an attempt that already ran gets the original
+      # response, and one of an attempt still running waits for it
+      key = request.get_header('HTTP_IDEMPOTENCY_KEY')
+      if key && !claim(key)
+        cached = await_completion(key)
+        return [200, { 'Content-Type' => 'application/json' }, [cached]] if cached
+
+        return [409, { 'Content-Type' => 'application/json' }, [{ error: 'Attempt in progress' }.to_json]]
+      end
+
       start_time = Time.now
 
       # In a real Rails app, we would do:
       # job_class = Object.const_get(job_class_name)
       # job_class.new(*args).perform
       
-      # For now, let's simulate the execution
-      $stdout.puts "[Sidecar] Executing Job: #{job_class_name} [#{jid}] with args: #{args.inspect}"
-      $stdout.flush
-      
-      # Simulate work using the first argument if it's a number
-      # In Sidekiq format, args is an array. Our enqueue script sends [name, duration]
-      duration = args[1].is_a?(Numeric) ? args[1] : 0.1
-      sleep duration 
-      
-      execution_time = Time.now - start_time
+      final = true
+      begin
+        # For now, let's simulate the execution
+        $stdout.puts "[Sidecar] Executing Job: #{job_class_name} [#{jid}] with args: #{args.inspect}"
+        $stdout.flush
 
-      [200, { 'Content-Type' => 'application/json' }, [{
-        status: 'success',
-        jid: jid,
-        execution_time: execution_time
-      }.to_json]]
+        # Simulate work using the first argument if it's a number
+        # In Sidekiq format, args is an array. Our enqueue script sends [name, duration]
+        duration = args[1].is_a?(Numeric) ? args[1] : 0.1
+        sleep duration
+
+        body = {
+          status: 'success',
+          jid: jid,
+          execution_time: Time.now - start_time
+        }.to_json
+      rescue StandardError => e
+        # Job errors are a successful response describing the failure, so the
+        # worker retries the job rather than the request
+        result = failure(jid, e, Time.now - start_time)
+        final = result[:discard]
+        body = result.to_json
+      end
+
+      complete(key, body, final) if key
+
+      [200, { 'Content-Type' => 'application/json' }, [body]]
     rescue JSON::ParserError
       [400, { 'Content-Type' => 'application/json' }, [{ error: 'Invalid JSON' }.to_json]]
     rescue NameError => e
       [404, { 'Content-Type' => 'application/json' }, [{ status: 'failure', error: "Job class not found: #{e.message}" }.to_json]]
     end
 
+    # failure describes a job exception for the worker. Exceptions may respond to
+    # retryable?, retry_in, discard? and tags to control how the worker handles them.
+    def failure(jid, error, execution_time)
+      {
+        status: 'failure',
+        jid: jid,
+        execution_time: execution_time,
+        error_class: error.class.name,
+        error_message: error.message,
+        backtrace: Array(error.backtrace).first(50),
+        retryable: error.respond_to?(:retryable?) ? error.retryable? : true,
+        retry_in: error.respond_to?(:retry_in) ? error.retry_in : nil,
+        discard: error.respond_to?(:discard?) ? error.discard? : false,
+        tags: error.respond_to?(:tags) ? Array(error.tags) : []
+      }.compact
+    end
+
+    def idempotency_key(key)
+      "#{@prefix}sidecar:idempotency:#{key}"
+    end
+
+    # claim marks the attempt as running, returning false if it already ran or is running
+    def claim(key)
+      @redis.set(idempotency_key(key), IN_PROGRESS, nx: true, ex: IN_PROGRESS_TTL)
+    end
+
+    # await_completion returns the recorded response of an attempt, waiting a
+    # while for one that is still running. It returns nil if none is recorded.
+    def await_completion(key)
+      deadline = Time.now + IN_PROGRESS_WAIT
+      loop do
+        value = @redis.get(idempotency_key(key))
+        return value unless value == IN_PROGRESS
+        return nil if Time.now >= deadline
+
+        sleep IN_PROGRESS_POLL
+      end
+    end
+
+    # complete records the response of a final attempt, or releases the key of
+    # a failed one so the worker's retry executes it again
+    def complete(key, body, final)
+      if final
+        @redis.set(idempotency_key(key), body, ex: COMPLETED_TTL)
+      else
+        @redis.del(idempotency_key(key))
+      end
+    end
+
     def handle_health(request)
       [200, { 'Content-Type' => 'application/json' }, [{ status: 'ok', timestamp: Time.now.to_i }.to_json]]
     end